@@ -0,0 +1,223 @@
+package cache
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"reflect"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	envelopeVersion  = 1
+	envelopeNegative = 1 << 0
+	envelopeHeader   = 10
+)
+
+var (
+	ErrMiss = errors.New("cache: miss")
+	// ErrNotFound is returned by a loader when the value does not exist.
+	// With Options.NegativeTTL set the absence itself is cached.
+	ErrNotFound = errors.New("cache: not found")
+)
+
+type Options struct {
+	// Codec serializes values, JSON by default.
+	Codec Codec
+	// NegativeTTL caches ErrNotFound from the loader for this long. Zero
+	// disables negative caching.
+	NegativeTTL time.Duration
+	// Jitter randomizes every TTL by up to this fraction, e.g. 0.1 for ±10%,
+	// so keys written together do not expire together.
+	Jitter float64
+	// StaleWhileRevalidate keeps serving an expired value for this long
+	// while a single background load refreshes it.
+	StaleWhileRevalidate time.Duration
+	// Prefix is prepended to every key.
+	Prefix string
+}
+
+type Cache struct {
+	store Store
+	opts  Options
+	group singleflight.Group
+}
+
+var (
+	defaultCache *Cache
+)
+
+func New(store Store, opts Options) *Cache {
+	if opts.Codec == nil {
+		opts.Codec = JSON
+	}
+	if opts.Jitter < 0 {
+		opts.Jitter = 0
+	}
+	return &Cache{
+		store: store,
+		opts:  opts,
+	}
+}
+
+// Init sets the cache used by GetOrLoad and Delete.
+func Init(store Store, opts Options) {
+	defaultCache = New(store, opts)
+}
+
+// GetOrLoad reads key from the default cache, calling loader on a miss and
+// caching its result for ttl.
+func GetOrLoad[T any](ctx context.Context, key string, ttl time.Duration, loader func(ctx context.Context) (T, error)) (T, error) {
+	return GetOrLoadFrom(ctx, defaultCache, key, ttl, loader)
+}
+
+// Delete removes keys from the default cache.
+func Delete(ctx context.Context, keys ...string) error {
+	return defaultCache.Delete(ctx, keys...)
+}
+
+// GetOrLoadFrom is GetOrLoad on an explicit cache. Concurrent misses on the
+// same key and T share a single loader call.
+func GetOrLoadFrom[T any](ctx context.Context, c *Cache, key string, ttl time.Duration, loader func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
+	if c == nil {
+		return loader(ctx)
+	}
+
+	load := func(ctx context.Context) (interface{}, error) {
+		return loader(ctx)
+	}
+	// Callers loading the same key as different types must not receive
+	// each other's results.
+	flight := reflect.TypeFor[T]().String() + "\x00" + key
+
+	raw, err := c.store.Get(ctx, c.key(key))
+	if err == nil {
+		if env, ok := decodeEnvelope(raw); ok {
+			fresh := time.Now().Before(env.softExpiry)
+			if fresh || c.opts.StaleWhileRevalidate > 0 {
+				if !fresh {
+					go c.group.Do(flight, func() (interface{}, error) {
+						return c.load(context.WithoutCancel(ctx), key, ttl, load)
+					})
+				}
+				if env.negative {
+					return zero, ErrNotFound
+				}
+				var v T
+				if err := c.opts.Codec.Unmarshal(env.payload, &v); err == nil {
+					return v, nil
+				}
+			}
+		}
+	}
+	// Store errors other than a miss fall through to the loader; the cache
+	// must never be the reason a read fails.
+
+	ch := c.group.DoChan(flight, func() (interface{}, error) {
+		return c.load(context.WithoutCancel(ctx), key, ttl, load)
+	})
+
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return zero, res.Err
+		}
+		if res.Val == nil {
+			return zero, nil
+		}
+		v, ok := res.Val.(T)
+		if !ok {
+			return zero, fmt.Errorf("cache: loaded %T for key %q, want %s", res.Val, key, reflect.TypeFor[T]())
+		}
+		return v, nil
+	}
+}
+
+func (c *Cache) Delete(ctx context.Context, keys ...string) error {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = c.key(key)
+	}
+	return c.store.Delete(ctx, prefixed...)
+}
+
+func (c *Cache) load(ctx context.Context, key string, ttl time.Duration, loader func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	v, err := loader(ctx)
+	if errors.Is(err, ErrNotFound) {
+		if c.opts.NegativeTTL > 0 {
+			c.write(ctx, key, c.jitter(c.opts.NegativeTTL), envelopeNegative, nil)
+		}
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := c.opts.Codec.Marshal(v)
+	if err == nil {
+		c.write(ctx, key, c.jitter(ttl), 0, payload)
+	}
+	return v, nil
+}
+
+func (c *Cache) write(ctx context.Context, key string, ttl time.Duration, flags byte, payload []byte) {
+	if ttl <= 0 {
+		return
+	}
+
+	raw := encodeEnvelope(envelope{
+		negative:   flags&envelopeNegative != 0,
+		softExpiry: time.Now().Add(ttl),
+		payload:    payload,
+	})
+	_ = c.store.Set(ctx, c.key(key), raw, ttl+c.opts.StaleWhileRevalidate)
+}
+
+func (c *Cache) jitter(ttl time.Duration) time.Duration {
+	if c.opts.Jitter == 0 || ttl <= 0 {
+		return ttl
+	}
+	factor := 1 + (rand.Float64()*2-1)*c.opts.Jitter
+	return time.Duration(float64(ttl) * factor)
+}
+
+func (c *Cache) key(key string) string {
+	return c.opts.Prefix + key
+}
+
+// envelope wraps every stored value with its soft expiry, which drives
+// stale-while-revalidate independently of the store TTL.
+type envelope struct {
+	negative   bool
+	softExpiry time.Time
+	payload    []byte
+}
+
+func encodeEnvelope(e envelope) []byte {
+	raw := make([]byte, envelopeHeader+len(e.payload))
+	raw[0] = envelopeVersion
+	if e.negative {
+		raw[1] = envelopeNegative
+	}
+	binary.BigEndian.PutUint64(raw[2:envelopeHeader], uint64(e.softExpiry.UnixNano()))
+	copy(raw[envelopeHeader:], e.payload)
+	return raw
+}
+
+func decodeEnvelope(raw []byte) (envelope, bool) {
+	if len(raw) < envelopeHeader || raw[0] != envelopeVersion {
+		return envelope{}, false
+	}
+	return envelope{
+		negative:   raw[1]&envelopeNegative != 0,
+		softExpiry: time.Unix(0, int64(binary.BigEndian.Uint64(raw[2:envelopeHeader]))),
+		payload:    raw[envelopeHeader:],
+	}, true
+}
@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lru is a size- and count-bounded map with per-entry expiry.
+type lru struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int64
+	bytes      int64
+	ll         *list.List
+	items      map[string]*list.Element
+	onEvict    func()
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+func newLRU(maxEntries int, maxBytes int64, onEvict func()) *lru {
+	return &lru{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+		onEvict:    onEvict,
+	}
+}
+
+func (c *lru) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*lruEntry)
+	if time.Now().After(e.expires) {
+		c.removeElement(el)
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return e.value, true
+}
+
+func (c *lru) set(key string, value []byte, ttl time.Duration) {
+	size := entrySize(key, value)
+	if c.maxBytes > 0 && size > c.maxBytes {
+		// Never cache a value that would evict everything else.
+		c.remove(key)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+
+	el := c.ll.PushFront(&lruEntry{key: key, value: value, expires: time.Now().Add(ttl)})
+	c.items[key] = el
+	c.bytes += size
+
+	for c.overLimit() {
+		oldest := c.ll.Back()
+		if oldest == nil {
+			break
+		}
+		c.removeElement(oldest)
+		if c.onEvict != nil {
+			c.onEvict()
+		}
+	}
+}
+
+func (c *lru) remove(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if ok {
+		c.removeElement(el)
+	}
+	return ok
+}
+
+func (c *lru) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+	c.bytes = 0
+}
+
+func (c *lru) len() (int, int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len(), c.bytes
+}
+
+func (c *lru) overLimit() bool {
+	return (c.maxEntries > 0 && c.ll.Len() > c.maxEntries) ||
+		(c.maxBytes > 0 && c.bytes > c.maxBytes)
+}
+
+func (c *lru) removeElement(el *list.Element) {
+	e := el.Value.(*lruEntry)
+	c.ll.Remove(el)
+	delete(c.items, e.key)
+	c.bytes -= entrySize(e.key, e.value)
+}
+
+func entrySize(key string, value []byte) int64 {
+	return int64(len(key) + len(value))
+}
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/danielpnjt/go-library/redis"
+)
+
+// Store is the byte-level backend behind Cache. Get returns ErrMiss when
+// the key is absent.
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+type redisStore struct {
+	redis *redis.RedisOop
+}
+
+// NewRedisStore stores cache entries as plain strings on r.
+func NewRedisStore(r *redis.RedisOop) Store {
+	return &redisStore{
+		redis: r,
+	}
+}
+
+func (s *redisStore) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := s.redis.GetContext(ctx, key)
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMiss
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte(value), nil
+}
+
+func (s *redisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.redis.SetRedisStringContext(ctx, key, string(value), ttl)
+}
+
+func (s *redisStore) Delete(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		if err := s.redis.DeleteContext(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
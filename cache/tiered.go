@@ -0,0 +1,226 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/danielpnjt/go-library/redis"
+)
+
+const (
+	TierRedis    = "redis"
+	TierTwoLevel = "two-level"
+
+	defaultLocalTTL            = time.Minute
+	defaultLocalMaxEntries     = 10000
+	defaultInvalidationChannel = "cache:invalidate"
+)
+
+type LocalOptions struct {
+	// MaxEntries and MaxBytes bound the in-process tier; the least recently
+	// used entries are evicted first. MaxBytes counts keys and values.
+	MaxEntries int
+	MaxBytes   int64
+	// TTL caps how long an entry lives locally, which bounds staleness if an
+	// invalidation message is missed. One minute by default.
+	TTL time.Duration
+	// Channel carries invalidations between replicas.
+	Channel string
+}
+
+type TieredStats struct {
+	Hits          uint64 `json:"hits"`
+	Misses        uint64 `json:"misses"`
+	Evictions     uint64 `json:"evictions"`
+	Invalidations uint64 `json:"invalidations"`
+	Entries       int    `json:"entries"`
+	Bytes         int64  `json:"bytes"`
+}
+
+// TieredStore is a Store serving hot keys from process memory and the rest
+// from Redis. Writes and deletes are broadcast so other replicas drop their
+// local copy.
+type TieredStore struct {
+	remote    Store
+	redis     *redis.RedisOop
+	local     *lru
+	opts      LocalOptions
+	id        string
+	pubsub    *redis.PubSub
+	done      chan struct{}
+	closeOnce sync.Once
+	// listening is cleared when the invalidation listener exits; the local
+	// tier is bypassed from then on since it would never be invalidated.
+	listening atomic.Bool
+
+	hits          atomic.Uint64
+	misses        atomic.Uint64
+	evictions     atomic.Uint64
+	invalidations atomic.Uint64
+}
+
+// NewStore returns the plain Redis store for TierRedis and a TieredStore for
+// TierTwoLevel, so the tier can be picked from configuration.
+func NewStore(ctx context.Context, tier string, r *redis.RedisOop, opts LocalOptions) (Store, error) {
+	switch tier {
+	case TierRedis, "":
+		return NewRedisStore(r), nil
+	case TierTwoLevel:
+		return NewTieredStore(ctx, r, opts)
+	default:
+		return nil, fmt.Errorf("cache: unknown tier %q", tier)
+	}
+}
+
+// NewTieredStore subscribes to invalidations; ctx only bounds the wait for
+// the subscription. The listener runs until Close is called.
+func NewTieredStore(ctx context.Context, r *redis.RedisOop, opts LocalOptions) (*TieredStore, error) {
+	if opts.TTL <= 0 {
+		opts.TTL = defaultLocalTTL
+	}
+	if opts.MaxEntries <= 0 && opts.MaxBytes <= 0 {
+		opts.MaxEntries = defaultLocalMaxEntries
+	}
+	if opts.Channel == "" {
+		opts.Channel = defaultInvalidationChannel
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	s := &TieredStore{
+		remote: NewRedisStore(r),
+		redis:  r,
+		opts:   opts,
+		id:     hex.EncodeToString(id),
+		done:   make(chan struct{}),
+	}
+	s.local = newLRU(opts.MaxEntries, opts.MaxBytes, func() {
+		s.evictions.Add(1)
+	})
+
+	s.pubsub = r.Subscribe(context.WithoutCancel(ctx), opts.Channel)
+	// Wait for the subscription to be confirmed so no invalidation published
+	// after this constructor returns is missed.
+	if _, err := s.pubsub.Receive(ctx); err != nil {
+		s.pubsub.Close()
+		return nil, err
+	}
+
+	s.listening.Store(true)
+	go s.listen()
+
+	return s, nil
+}
+
+func (s *TieredStore) Get(ctx context.Context, key string) ([]byte, error) {
+	if !s.listening.Load() {
+		return s.remote.Get(ctx, key)
+	}
+	if value, ok := s.local.get(key); ok {
+		s.hits.Add(1)
+		return value, nil
+	}
+	s.misses.Add(1)
+
+	value, err := s.remote.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	s.local.set(key, value, s.opts.TTL)
+	return value, nil
+}
+
+func (s *TieredStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := s.remote.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+
+	if s.listening.Load() {
+		localTTL := s.opts.TTL
+		if ttl > 0 && ttl < localTTL {
+			localTTL = ttl
+		}
+		s.local.set(key, value, localTTL)
+	}
+	s.broadcast(ctx, key)
+	return nil
+}
+
+func (s *TieredStore) Delete(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		s.local.remove(key)
+	}
+	if err := s.remote.Delete(ctx, keys...); err != nil {
+		return err
+	}
+	s.broadcast(ctx, keys...)
+	return nil
+}
+
+func (s *TieredStore) Stats() TieredStats {
+	entries, bytes := s.local.len()
+	return TieredStats{
+		Hits:          s.hits.Load(),
+		Misses:        s.misses.Load(),
+		Evictions:     s.evictions.Load(),
+		Invalidations: s.invalidations.Load(),
+		Entries:       entries,
+		Bytes:         bytes,
+	}
+}
+
+// Close stops listening for invalidations. Afterwards reads and writes go
+// straight to Redis.
+func (s *TieredStore) Close(ctx context.Context) error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.done)
+		err = s.pubsub.Close()
+	})
+	return err
+}
+
+// broadcast is best effort: a lost message only delays invalidation until
+// the local TTL runs out.
+func (s *TieredStore) broadcast(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		_ = s.redis.Publish(ctx, s.opts.Channel, s.id+"|"+key)
+	}
+}
+
+func (s *TieredStore) listen() {
+	defer func() {
+		s.listening.Store(false)
+		s.local.clear()
+	}()
+
+	ch := s.pubsub.Channel()
+	for {
+		select {
+		case <-s.done:
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			origin, key, found := strings.Cut(msg.Payload, "|")
+			if !found || origin == s.id {
+				continue
+			}
+			if s.local.remove(key) {
+				s.invalidations.Add(1)
+			}
+		}
+	}
+}
+
+var _ Store = (*TieredStore)(nil)
@@ -12,22 +12,56 @@ const (
 	bodyKey       = "body"
 	thirdPartyKey = "thirdParty"
 	respKey       = "resp"
+	primaryKey    = "primary"
 )
 
-func SetTraceFromContext(ctx context.Context, trace []interface{}) context.Context {
-	ctx = context.WithValue(ctx, traceKey, trace)
+// SetTraceEntriesFromContext returns ctx carrying a new trace chain that
+// starts with trace.
+func SetTraceEntriesFromContext(ctx context.Context, trace []TraceEntry) context.Context {
+	chain := &traceChain{
+		entries: append([]TraceEntry(nil), trace...),
+	}
+	ctx = context.WithValue(ctx, traceKey, chain)
 	return ctx
 }
 
-func GetTraceFromContext(ctx context.Context) []interface{} {
-	lr := ctx.Value(traceKey)
-	if l, ok := lr.([]interface{}); ok {
-		return l
+// GetTraceEntriesFromContext returns a copy of the trace chain of ctx.
+func GetTraceEntriesFromContext(ctx context.Context) []TraceEntry {
+	if c := getTraceChain(ctx); c != nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return append([]TraceEntry{}, c.entries...)
 	} else {
-		return []interface{}{}
+		return []TraceEntry{}
 	}
 }
 
+// SetTraceFromContext is SetTraceEntriesFromContext for untyped entries.
+// Values that are not a TraceEntry are dropped.
+//
+// Deprecated: use SetTraceEntriesFromContext.
+func SetTraceFromContext(ctx context.Context, trace []interface{}) context.Context {
+	entries := make([]TraceEntry, 0, len(trace))
+	for _, t := range trace {
+		if e, ok := t.(TraceEntry); ok {
+			entries = append(entries, e)
+		}
+	}
+	return SetTraceEntriesFromContext(ctx, entries)
+}
+
+// GetTraceFromContext is GetTraceEntriesFromContext as untyped values.
+//
+// Deprecated: use GetTraceEntriesFromContext.
+func GetTraceFromContext(ctx context.Context) []interface{} {
+	entries := GetTraceEntriesFromContext(ctx)
+	trace := make([]interface{}, len(entries))
+	for i, e := range entries {
+		trace[i] = e
+	}
+	return trace
+}
+
 func GetBody(r *http.Request) []byte {
 	lr := r.Context().Value(bodyKey)
 	if l, ok := lr.([]byte); ok {
@@ -71,3 +105,15 @@ func GetResponseFromContext(ctx context.Context) *response.Response {
 		return &response.Response{}
 	}
 }
+
+// SetForcePrimaryFromContext makes database reads made with ctx go to the
+// primary, e.g. right after a write that a replica may not have seen yet.
+func SetForcePrimaryFromContext(ctx context.Context) context.Context {
+	ctx = context.WithValue(ctx, primaryKey, true)
+	return ctx
+}
+
+func GetForcePrimaryFromContext(ctx context.Context) bool {
+	force, _ := ctx.Value(primaryKey).(bool)
+	return force
+}
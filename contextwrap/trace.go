@@ -0,0 +1,344 @@
+package contextwrap
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	TraceKindHttp  = "http"
+	TraceKindMinio = "minio"
+	TraceKindDB    = "db"
+	TraceKindRedis = "redis"
+	TraceKindSftp  = "sftp"
+	TraceKindQueue = "queue"
+
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// TraceEntry is a single outbound call recorded on the request trace.
+type TraceEntry interface {
+	Kind() string
+	Target() string
+	StartTime() time.Time
+	Duration() time.Duration
+	Outcome() string
+	Err() error
+	Attributes() map[string]interface{}
+}
+
+// TraceMeta carries the timing and outcome shared by every trace entry.
+// It is embedded by the concrete entries and hidden from their JSON form.
+type TraceMeta struct {
+	Start time.Time     `json:"-"`
+	Took  time.Duration `json:"-"`
+	Error error         `json:"-"`
+}
+
+func NewTraceMeta(start time.Time, err error) TraceMeta {
+	return TraceMeta{
+		Start: start,
+		Took:  time.Since(start),
+		Error: err,
+	}
+}
+
+func (m TraceMeta) StartTime() time.Time {
+	return m.Start
+}
+
+func (m TraceMeta) Duration() time.Duration {
+	return m.Took
+}
+
+func (m TraceMeta) Err() error {
+	return m.Error
+}
+
+func (m TraceMeta) Outcome() string {
+	if m.Error != nil {
+		return OutcomeFailure
+	}
+	return OutcomeSuccess
+}
+
+type TraceHttp struct {
+	TraceMeta
+	Request    interface{} `json:"request"`
+	Response   interface{} `json:"response"`
+	Url        string      `json:"url"`
+	Method     string      `json:"method,omitempty"`
+	StatusCode int         `json:"status_code,omitempty"`
+	Elapsed    string      `json:"elapsed"`
+}
+
+func (t *TraceHttp) Kind() string {
+	return TraceKindHttp
+}
+
+func (t *TraceHttp) Target() string {
+	return t.Url
+}
+
+func (t *TraceHttp) Attributes() map[string]interface{} {
+	return map[string]interface{}{
+		"method":      t.Method,
+		"status_code": t.StatusCode,
+		"request":     t.Request,
+		"response":    t.Response,
+	}
+}
+
+type TraceMinio struct {
+	TraceMeta
+	Host       string `json:"host"`
+	Operation  string `json:"operation,omitempty"`
+	ObjectName string `json:"object_name"`
+	BucketName string `json:"bucket_name"`
+	Elapsed    string `json:"elapsed"`
+}
+
+func (t *TraceMinio) Kind() string {
+	return TraceKindMinio
+}
+
+func (t *TraceMinio) Target() string {
+	return t.Host
+}
+
+func (t *TraceMinio) Attributes() map[string]interface{} {
+	return map[string]interface{}{
+		"operation":   t.Operation,
+		"bucket_name": t.BucketName,
+		"object_name": t.ObjectName,
+	}
+}
+
+type TraceDB struct {
+	TraceMeta
+	Driver       string `json:"driver"`
+	Host         string `json:"host"`
+	Statement    string `json:"statement"`
+	RowsAffected int64  `json:"rows_affected"`
+	Elapsed      string `json:"elapsed"`
+}
+
+func (t *TraceDB) Kind() string {
+	return TraceKindDB
+}
+
+func (t *TraceDB) Target() string {
+	return t.Host
+}
+
+func (t *TraceDB) Attributes() map[string]interface{} {
+	return map[string]interface{}{
+		"driver":        t.Driver,
+		"statement":     t.Statement,
+		"rows_affected": t.RowsAffected,
+	}
+}
+
+type TraceRedis struct {
+	TraceMeta
+	Host    string `json:"host"`
+	Command string `json:"command"`
+	Key     string `json:"key"`
+	Elapsed string `json:"elapsed"`
+}
+
+func (t *TraceRedis) Kind() string {
+	return TraceKindRedis
+}
+
+func (t *TraceRedis) Target() string {
+	return t.Host
+}
+
+func (t *TraceRedis) Attributes() map[string]interface{} {
+	return map[string]interface{}{
+		"command": t.Command,
+		"key":     t.Key,
+	}
+}
+
+type TraceSftp struct {
+	TraceMeta
+	Host      string `json:"host"`
+	Operation string `json:"operation"`
+	Path      string `json:"path"`
+	Bytes     int64  `json:"bytes"`
+	Elapsed   string `json:"elapsed"`
+}
+
+func (t *TraceSftp) Kind() string {
+	return TraceKindSftp
+}
+
+func (t *TraceSftp) Target() string {
+	return t.Host
+}
+
+func (t *TraceSftp) Attributes() map[string]interface{} {
+	return map[string]interface{}{
+		"operation": t.Operation,
+		"path":      t.Path,
+		"bytes":     t.Bytes,
+	}
+}
+
+type TraceQueue struct {
+	TraceMeta
+	Broker      string `json:"broker"`
+	Operation   string `json:"operation"`
+	Destination string `json:"destination"`
+	MessageID   string `json:"message_id,omitempty"`
+	Elapsed     string `json:"elapsed"`
+}
+
+func (t *TraceQueue) Kind() string {
+	return TraceKindQueue
+}
+
+func (t *TraceQueue) Target() string {
+	return t.Destination
+}
+
+func (t *TraceQueue) Attributes() map[string]interface{} {
+	return map[string]interface{}{
+		"broker":     t.Broker,
+		"operation":  t.Operation,
+		"message_id": t.MessageID,
+	}
+}
+
+// traceChain is shared by every context derived from the one it was stored
+// in, so entries appended deep in a call stack stay visible to the caller.
+type traceChain struct {
+	mu      sync.Mutex
+	entries []TraceEntry
+}
+
+func getTraceChain(ctx context.Context) *traceChain {
+	if c, ok := ctx.Value(traceKey).(*traceChain); ok {
+		return c
+	}
+	return nil
+}
+
+// StartTrace returns ctx carrying an empty trace chain, or ctx itself when
+// it already has one. Wrappers whose methods do not hand back a context
+// (Redis, MySQL, PostgreSQL, SFTP) can only record into a chain that exists
+// before they are called, so start one per request; TraceMiddleware does.
+func StartTrace(ctx context.Context) context.Context {
+	if getTraceChain(ctx) != nil {
+		return ctx
+	}
+	return SetTraceEntriesFromContext(ctx, nil)
+}
+
+// TraceMiddleware starts a trace chain for every request.
+func TraceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(StartTrace(r.Context())))
+	})
+}
+
+// RecordTrace records entries on the chain of ctx. It reports false and
+// drops them when ctx has no chain; see StartTrace.
+func RecordTrace(ctx context.Context, entries ...TraceEntry) bool {
+	c := getTraceChain(ctx)
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	c.entries = append(c.entries, entries...)
+	c.mu.Unlock()
+	return true
+}
+
+// AppendTrace records entries on the trace chain of ctx. When ctx has no
+// chain yet a new one is started and returned in the derived context, so
+// callers must use the returned context.
+func AppendTrace(ctx context.Context, entries ...TraceEntry) context.Context {
+	if RecordTrace(ctx, entries...) {
+		return ctx
+	}
+	return SetTraceEntriesFromContext(ctx, entries)
+}
+
+type traceRecord struct {
+	Kind      string                 `json:"kind"`
+	Target    string                 `json:"target"`
+	Start     string                 `json:"start,omitempty"`
+	ElapsedMs float64                `json:"elapsed_ms"`
+	Outcome   string                 `json:"outcome"`
+	Error     string                 `json:"error,omitempty"`
+	Attrs     map[string]interface{} `json:"attrs,omitempty"`
+}
+
+// MarshalTrace renders entries as a compact JSON array for the transaction log.
+func MarshalTrace(entries []TraceEntry) ([]byte, error) {
+	records := make([]traceRecord, 0, len(entries))
+	for _, e := range entries {
+		if e == nil {
+			continue
+		}
+
+		rec := traceRecord{
+			Kind:      e.Kind(),
+			Target:    e.Target(),
+			ElapsedMs: float64(e.Duration().Microseconds()) / 1000,
+			Outcome:   e.Outcome(),
+			Attrs:     compactAttributes(e.Attributes()),
+		}
+		if start := e.StartTime(); !start.IsZero() {
+			rec.Start = start.Format(time.RFC3339Nano)
+		}
+		if err := e.Err(); err != nil {
+			rec.Error = err.Error()
+		}
+
+		records = append(records, rec)
+	}
+
+	return json.Marshal(records)
+}
+
+// TraceJSON is MarshalTrace over the chain stored in ctx.
+func TraceJSON(ctx context.Context) string {
+	js, err := MarshalTrace(GetTraceEntriesFromContext(ctx))
+	if err != nil {
+		return "[]"
+	}
+	return string(js)
+}
+
+func compactAttributes(attrs map[string]interface{}) map[string]interface{} {
+	for k, v := range attrs {
+		switch val := v.(type) {
+		case nil:
+			delete(attrs, k)
+		case string:
+			if val == "" {
+				delete(attrs, k)
+			}
+		case int:
+			if val == 0 {
+				delete(attrs, k)
+			}
+		case int64:
+			if val == 0 {
+				delete(attrs, k)
+			}
+		}
+	}
+	if len(attrs) == 0 {
+		return nil
+	}
+	return attrs
+}
@@ -0,0 +1,93 @@
+package contextwrap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMarshalTrace(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	entries := []TraceEntry{
+		&TraceHttp{
+			TraceMeta:  TraceMeta{Start: start, Took: 1500 * time.Microsecond},
+			Url:        "https://api.example.com/v1",
+			Method:     "POST",
+			StatusCode: 201,
+		},
+		&TraceDB{
+			TraceMeta: TraceMeta{Start: start, Took: time.Millisecond, Error: errors.New("boom")},
+			Driver:    "mysql",
+			Host:      "db:3306",
+			Statement: "SELECT * FROM users WHERE id = ?",
+		},
+		&TraceQueue{
+			TraceMeta:   TraceMeta{Start: start, Took: 2 * time.Millisecond},
+			Broker:      "redis",
+			Operation:   "publish",
+			Destination: "orders",
+			MessageID:   "1-0",
+		},
+		nil,
+	}
+
+	js, err := MarshalTrace(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []traceRecord
+	if err := json.Unmarshal(js, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d records, want 3: %s", len(got), js)
+	}
+
+	tests := []struct {
+		kind, target, outcome, err string
+		elapsedMs                  float64
+		attrs                      map[string]interface{}
+	}{
+		{TraceKindHttp, "https://api.example.com/v1", OutcomeSuccess, "", 1.5,
+			map[string]interface{}{"method": "POST", "status_code": float64(201)}},
+		{TraceKindDB, "db:3306", OutcomeFailure, "boom", 1,
+			map[string]interface{}{"driver": "mysql", "statement": "SELECT * FROM users WHERE id = ?"}},
+		{TraceKindQueue, "orders", OutcomeSuccess, "", 2,
+			map[string]interface{}{"broker": "redis", "operation": "publish", "message_id": "1-0"}},
+	}
+	for i, tt := range tests {
+		rec := got[i]
+		if rec.Kind != tt.kind || rec.Target != tt.target || rec.Outcome != tt.outcome || rec.Error != tt.err || rec.ElapsedMs != tt.elapsedMs {
+			t.Errorf("record %d = %+v", i, rec)
+		}
+		if rec.Start != "2024-01-02T03:04:05Z" {
+			t.Errorf("record %d start = %q", i, rec.Start)
+		}
+		if len(rec.Attrs) != len(tt.attrs) {
+			t.Errorf("record %d attrs = %v, want %v", i, rec.Attrs, tt.attrs)
+			continue
+		}
+		for k, v := range tt.attrs {
+			if rec.Attrs[k] != v {
+				t.Errorf("record %d attr %s = %v, want %v", i, k, rec.Attrs[k], v)
+			}
+		}
+	}
+}
+
+func TestDeprecatedTraceAccessors(t *testing.T) {
+	q := &TraceQueue{Destination: "orders"}
+	ctx := SetTraceFromContext(context.Background(), []interface{}{q, "not an entry"})
+	RecordTrace(ctx, &TraceRedis{Host: "redis:6379"})
+
+	got := GetTraceFromContext(ctx)
+	if len(got) != 2 || got[0] != q {
+		t.Fatalf("GetTraceFromContext = %v", got)
+	}
+	if entries := GetTraceEntriesFromContext(ctx); len(entries) != 2 {
+		t.Fatalf("GetTraceEntriesFromContext = %v", entries)
+	}
+}
@@ -0,0 +1,126 @@
+// Package dberr classifies MySQL and PostgreSQL driver errors so callers can
+// use errors.Is instead of matching messages:
+//
+//	if errors.Is(err, dberr.ErrDuplicateKey) {
+//		return conflict()
+//	}
+package dberr
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"strconv"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	ErrDuplicateKey  = errors.New("dberr: duplicate key")
+	ErrForeignKey    = errors.New("dberr: foreign key violation")
+	ErrDeadlock      = errors.New("dberr: deadlock")
+	ErrSerialization = errors.New("dberr: serialization failure")
+	ErrTimeout       = errors.New("dberr: timeout")
+	ErrConnection    = errors.New("dberr: connection failure")
+	ErrNoRows        = errors.New("dberr: no rows")
+)
+
+// Error is a driver error together with its class. errors.Is matches both
+// the class and anything the driver error wraps.
+type Error struct {
+	Kind error
+	// Code is the MySQL error number or the PostgreSQL SQLSTATE, when the
+	// server reported one.
+	Code string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// MySQL error numbers.
+var mysqlKinds = map[uint16]error{
+	1062: ErrDuplicateKey, // ER_DUP_ENTRY
+	1586: ErrDuplicateKey, // ER_DUP_ENTRY_WITH_KEY_NAME
+	1216: ErrForeignKey,   // ER_NO_REFERENCED_ROW
+	1217: ErrForeignKey,   // ER_ROW_IS_REFERENCED
+	1451: ErrForeignKey,   // ER_ROW_IS_REFERENCED_2
+	1452: ErrForeignKey,   // ER_NO_REFERENCED_ROW_2
+	1213: ErrDeadlock,     // ER_LOCK_DEADLOCK
+	1205: ErrTimeout,      // ER_LOCK_WAIT_TIMEOUT
+	3024: ErrTimeout,      // ER_QUERY_TIMEOUT
+	1040: ErrConnection,   // ER_CON_COUNT_ERROR
+	1053: ErrConnection,   // ER_SERVER_SHUTDOWN
+}
+
+// PostgreSQL SQLSTATE codes. Class 08 is matched separately.
+var postgresKinds = map[string]error{
+	"23505": ErrDuplicateKey,  // unique_violation
+	"23503": ErrForeignKey,    // foreign_key_violation
+	"40P01": ErrDeadlock,      // deadlock_detected
+	"40001": ErrSerialization, // serialization_failure
+	"57014": ErrTimeout,       // query_canceled, also statement_timeout
+	"55P03": ErrTimeout,       // lock_not_available
+	"53300": ErrConnection,    // too_many_connections
+	"57P01": ErrConnection,    // admin_shutdown
+}
+
+// Classify wraps err in an *Error when it is a known driver error and
+// returns it unchanged otherwise. It is safe to call on nil and on errors
+// that were already classified.
+func Classify(err error) error {
+	if err == nil {
+		return nil
+	}
+	var classified *Error
+	if errors.As(err, &classified) {
+		return err
+	}
+
+	var (
+		mysqlErr *mysqldriver.MySQLError
+		pgErr    *pgconn.PgError
+		connErr  *pgconn.ConnectError
+		netErr   net.Error
+	)
+	switch {
+	case errors.As(err, &mysqlErr):
+		if kind, ok := mysqlKinds[mysqlErr.Number]; ok {
+			return &Error{Kind: kind, Code: strconv.Itoa(int(mysqlErr.Number)), Err: err}
+		}
+	case errors.As(err, &pgErr):
+		if kind, ok := postgresKinds[pgErr.Code]; ok {
+			return &Error{Kind: kind, Code: pgErr.Code, Err: err}
+		}
+		if len(pgErr.Code) == 5 && pgErr.Code[:2] == "08" {
+			return &Error{Kind: ErrConnection, Code: pgErr.Code, Err: err}
+		}
+	case errors.Is(err, sql.ErrNoRows), errors.Is(err, pgx.ErrNoRows):
+		return &Error{Kind: ErrNoRows, Err: err}
+	case errors.Is(err, context.DeadlineExceeded), pgconn.Timeout(err):
+		return &Error{Kind: ErrTimeout, Err: err}
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return &Error{Kind: ErrTimeout, Err: err}
+	case errors.As(err, &connErr), errors.As(err, &netErr),
+		errors.Is(err, driver.ErrBadConn), errors.Is(err, mysqldriver.ErrInvalidConn),
+		errors.Is(err, sql.ErrConnDone):
+		return &Error{Kind: ErrConnection, Err: err}
+	}
+	return err
+}
+
+// IsRetryable reports whether err is a deadlock or serialization failure,
+// after which the whole transaction can safely be run again.
+func IsRetryable(err error) bool {
+	err = Classify(err)
+	return errors.Is(err, ErrDeadlock) || errors.Is(err, ErrSerialization)
+}
@@ -0,0 +1,42 @@
+package dberr
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+const (
+	defaultAttempts = 3
+	retryBaseDelay  = 20 * time.Millisecond
+	retryMaxDelay   = time.Second
+)
+
+// Retry runs fn until it succeeds, fails with an error that is not
+// retryable, or has run attempts times (three when attempts <= 0). fn
+// should run a whole transaction, e.g. MysqlOop.Transaction, since a
+// deadlock or serialization failure rolls back everything before it.
+// Between attempts it sleeps with jittered exponential backoff.
+func Retry(ctx context.Context, attempts int, fn func(ctx context.Context) error) error {
+	if attempts <= 0 {
+		attempts = defaultAttempts
+	}
+
+	delay := retryBaseDelay
+	for attempt := 1; ; attempt++ {
+		err := Classify(fn(ctx))
+		if err == nil || attempt >= attempts || !IsRetryable(err) {
+			return err
+		}
+
+		sleep := delay/2 + time.Duration(rand.Int63n(int64(delay)))
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(sleep):
+		}
+		if delay *= 2; delay > retryMaxDelay {
+			delay = retryMaxDelay
+		}
+	}
+}
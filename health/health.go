@@ -0,0 +1,179 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+
+	defaultTimeout = 3 * time.Second
+)
+
+// Checker is implemented by every client wrapper that can report whether
+// its dependency is reachable.
+type Checker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// CheckerFunc adapts a plain function to Checker.
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) HealthCheck(ctx context.Context) error {
+	return f(ctx)
+}
+
+type Result struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+type check struct {
+	name    string
+	checker Checker
+}
+
+// Health aggregates dependency checks for liveness and readiness probes.
+type Health struct {
+	mu        sync.RWMutex
+	timeout   time.Duration
+	liveness  []check
+	readiness []check
+}
+
+// New returns an aggregator that gives every check at most timeout to answer.
+func New(timeout time.Duration) *Health {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &Health{
+		timeout: timeout,
+	}
+}
+
+// Register adds a readiness check, served on /readyz.
+func (h *Health) Register(name string, c Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.readiness = append(h.readiness, check{name: name, checker: c})
+}
+
+// RegisterLiveness adds a liveness check, served on /healthz. Keep these to
+// checks whose failure means the process must be restarted.
+func (h *Health) RegisterLiveness(name string, c Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.liveness = append(h.liveness, check{name: name, checker: c})
+}
+
+func (h *Health) Liveness(ctx context.Context) Report {
+	h.mu.RLock()
+	checks := append([]check(nil), h.liveness...)
+	h.mu.RUnlock()
+	return h.run(ctx, checks)
+}
+
+func (h *Health) Readiness(ctx context.Context) Report {
+	h.mu.RLock()
+	checks := append([]check(nil), h.readiness...)
+	h.mu.RUnlock()
+	return h.run(ctx, checks)
+}
+
+func (h *Health) run(ctx context.Context, checks []check) Report {
+	report := Report{
+		Status: StatusUp,
+		Checks: make(map[string]Result, len(checks)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c check) {
+			defer wg.Done()
+			res := h.runOne(ctx, c)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[c.name] = res
+			if res.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	return report
+}
+
+func (h *Health) runOne(ctx context.Context, c check) Result {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("health check panic: %v", r)
+			}
+		}()
+		done <- c.checker.HealthCheck(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	res := Result{
+		Status:   StatusUp,
+		Duration: time.Since(start).String(),
+	}
+	if err != nil {
+		res.Status = StatusDown
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// Handler serves /healthz and /readyz as JSON, answering 503 when any check
+// is down.
+func (h *Health) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, h.Liveness(r.Context()))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, h.Readiness(r.Context()))
+	})
+	return mux
+}
+
+func writeReport(w http.ResponseWriter, report Report) {
+	status := http.StatusOK
+	if report.Status != StatusUp {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(report)
+}
@@ -11,36 +11,24 @@ import (
 
 	"github.com/danielpnjt/go-library/contextwrap"
 	"github.com/danielpnjt/go-library/log"
-
-	"go.elastic.co/apm/module/apmhttp"
+	"github.com/danielpnjt/go-library/metrics"
+	"github.com/danielpnjt/go-library/tracing"
 )
 
-type contextKey struct {
-	name string
-}
-
 var (
-	client   *http.Client
-	traceKey = contextKey{"trace"}
+	client *http.Client
 )
 
-type TraceHttp struct {
-	Request  interface{} `json:"request"`
-	Response interface{} `json:"response"`
-	Url      string      `json:"url"`
-	Elapsed  string      `json:"elapsed"`
-}
+type TraceHttp = contextwrap.TraceHttp
 
 func Init() {
 	client = &http.Client{
 		Timeout: 20 * time.Second,
 	}
-
-	client = apmhttp.WrapClient(client)
 }
 
 func InitWithParam(c *http.Client) {
-	client = apmhttp.WrapClient(c)
+	client = c
 }
 
 func Call(ctx context.Context, requestBody map[string]interface{}, header http.Header, endpoint string) (context.Context, []byte, http.Header, error) {
@@ -57,46 +45,70 @@ func Call(ctx context.Context, requestBody map[string]interface{}, header http.H
 		payload = bytes.NewReader(jsonRequest)
 	}
 
-	currentTrace := contextwrap.GetTraceFromContext(ctx)
+	tr := &TraceHttp{
+		Url:     endpoint,
+		Method:  http.MethodPost,
+		Request: log.Minify(requestBody),
+	}
 
-	request, err := http.NewRequest("POST", endpoint, payload)
+	request, err := http.NewRequest(http.MethodPost, endpoint, payload)
 	if err != nil {
 		return ctx, nil, nil, err
 	}
 
-	request.Header = header
+	spanCtx, span := tracing.StartSpan(ctx, "POST "+request.URL.Host, "external.http")
+	defer span.End()
+	span.SetAttribute("http.url", endpoint)
+
+	// record reports the outcome once, the same way to the span, metrics
+	// and the trace chain.
+	record := func(err error) context.Context {
+		if err != nil {
+			span.RecordError(err)
+		}
+		tr.TraceMeta = contextwrap.NewTraceMeta(start, err)
+		tr.Elapsed = tr.Took.String()
+		metrics.Observe(metrics.ComponentHttp, http.MethodPost, metricTarget(endpoint), start, err)
+		return contextwrap.AppendTrace(ctx, tr)
+	}
 
-	response, err := client.Do(request.WithContext(ctx))
+	request.Header = header.Clone()
+	if request.Header == nil {
+		request.Header = http.Header{}
+	}
+	tracing.Inject(spanCtx, tracing.HeaderCarrier(request.Header))
+
+	response, err := client.Do(request.WithContext(spanCtx))
 	if err != nil {
-		return ctx, nil, nil, err
+		return record(err), nil, nil, err
 	}
 
 	defer response.Body.Close()
 
+	tr.StatusCode = response.StatusCode
+	span.SetAttribute("http.status_code", response.StatusCode)
+
 	responseByte, err := io.ReadAll(response.Body)
 	if err != nil {
-		return ctx, nil, nil, err
-	}
-
-	elapsed := time.Since(start).String()
-
-	tr := &TraceHttp{
-		Url:     endpoint,
-		Request: log.Minify(requestBody),
-		Elapsed: elapsed,
+		return record(err), nil, nil, err
 	}
 
-	currentTrace = append(currentTrace, tr)
-
-	ctx = context.WithValue(ctx, traceKey, currentTrace)
-
 	var js map[string]interface{}
 	err = json.Unmarshal(responseByte, &js)
 	if err != nil {
-		return ctx, nil, nil, err
+		return record(err), nil, nil, err
 	}
 
 	tr.Response = log.Minify(js)
 
-	return ctx, responseByte, response.Header, nil
+	return record(nil), responseByte, response.Header, nil
+}
+
+// metricTarget drops the query string so it cannot blow up label cardinality.
+func metricTarget(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return endpoint
+	}
+	return u.Host + u.Path
 }
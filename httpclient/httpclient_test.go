@@ -0,0 +1,108 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danielpnjt/go-library/contextwrap"
+	"github.com/danielpnjt/go-library/metrics"
+	"github.com/danielpnjt/go-library/tracing"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestCallInjectsTraceparent(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	tracing.Init(tracing.NewOTel(tp))
+	defer tracing.Init(tracing.Noop())
+
+	var traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.Write([]byte(`{"response_code":"00"}`))
+	}))
+	defer srv.Close()
+
+	Init()
+	ctx, parent := tracing.StartSpan(context.Background(), "parent", "test")
+	if _, _, _, err := Call(ctx, map[string]interface{}{"a": 1}, http.Header{}, srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	traceID := trace.SpanContextFromContext(ctx).TraceID().String()
+	if !strings.Contains(traceparent, traceID) {
+		t.Fatalf("traceparent = %q, want trace id %s", traceparent, traceID)
+	}
+
+	spans := exp.GetSpans()
+	if len(spans) != 2 || spans[0].Parent.SpanID() != trace.SpanContextFromContext(ctx).SpanID() {
+		t.Fatalf("want the call span as a child of parent, got %d spans", len(spans))
+	}
+	// The header carries the call span, not the parent.
+	if !strings.Contains(traceparent, spans[0].SpanContext.SpanID().String()) {
+		t.Fatalf("traceparent = %q, want span id %s", traceparent, spans[0].SpanContext.SpanID())
+	}
+}
+
+type errRecorder struct {
+	errs []error
+}
+
+func (r *errRecorder) ObserveRequest(component, operation, target string, elapsed time.Duration, err error) {
+	r.errs = append(r.errs, err)
+}
+
+func TestCallFailureIsRecordedEverywhere(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"invalid json", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("<html>bad gateway</html>"))
+		}},
+		{"truncated body", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Length", "100")
+			w.Write([]byte(`{"response_code":`))
+		}},
+	}
+
+	Init()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp := tracetest.NewInMemoryExporter()
+			tracing.Init(tracing.NewOTel(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))))
+			defer tracing.Init(tracing.Noop())
+			rec := &errRecorder{}
+			metrics.Init(rec)
+			defer metrics.Init(nil)
+
+			srv := httptest.NewServer(tt.handler)
+			defer srv.Close()
+
+			ctx, _, _, err := Call(contextwrap.StartTrace(context.Background()), map[string]interface{}{"a": 1}, http.Header{}, srv.URL)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+
+			if len(rec.errs) != 1 || rec.errs[0] == nil {
+				t.Errorf("metrics errors = %v, want the call error", rec.errs)
+			}
+			spans := exp.GetSpans()
+			if len(spans) != 1 || spans[0].Status.Code != codes.Error {
+				t.Errorf("want one span with an error status, got %d spans", len(spans))
+			}
+			entries := contextwrap.GetTraceEntriesFromContext(ctx)
+			if len(entries) != 1 || entries[0].Err() == nil {
+				t.Errorf("want one failed trace entry, got %v", entries)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultLockTTL = time.Minute
+	defaultTTL     = 24 * time.Hour
+)
+
+type Options struct {
+	// Header carries the client supplied key. Defaults to "Idempotency-Key".
+	Header string
+	// LockTTL bounds how long an in-flight reservation survives a crashed
+	// holder. A live holder renews it every LockTTL/2, so work may run
+	// longer. Defaults to one minute.
+	LockTTL time.Duration
+	// TTL is how long completed results are replayed. Defaults to 24 hours.
+	TTL time.Duration
+	// Principal returns the caller a request belongs to, such as a user or
+	// API client ID, so two callers choosing the same key do not collide.
+	// Without it keys are shared by every caller of a route.
+	Principal func(r *http.Request) string
+}
+
+// Guard runs work at most once per key and replays the stored result to
+// duplicates.
+type Guard struct {
+	store Store
+	opts  Options
+}
+
+func New(store Store, opts Options) *Guard {
+	if opts.Header == "" {
+		opts.Header = "Idempotency-Key"
+	}
+	if opts.LockTTL <= 0 {
+		opts.LockTTL = defaultLockTTL
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = defaultTTL
+	}
+	return &Guard{
+		store: store,
+		opts:  opts,
+	}
+}
+
+// Do runs fn once for key. A duplicate of a completed key gets the stored
+// record back with replayed set; a duplicate of a running key gets
+// ErrInFlight. A duplicate whose fingerprint differs from the first call's
+// gets ErrFingerprintMismatch. The reservation is renewed while fn runs,
+// and when fn fails or panics it is dropped so the work can be retried.
+func (g *Guard) Do(ctx context.Context, key, fingerprint string, fn func(ctx context.Context) (Record, error)) (rec Record, replayed bool, err error) {
+	stored, err := g.store.Reserve(ctx, key, fingerprint, g.opts.LockTTL)
+	if err != nil {
+		return Record{}, false, err
+	}
+	if stored != nil {
+		return *stored, true, nil
+	}
+
+	stop := g.extend(context.WithoutCancel(ctx), key, fingerprint)
+	completed := false
+	defer func() {
+		stop()
+		if !completed {
+			_ = g.store.Release(context.WithoutCancel(ctx), key, fingerprint)
+		}
+	}()
+
+	rec, err = fn(ctx)
+	if err != nil {
+		return rec, false, err
+	}
+
+	stop()
+	completed = true
+	rec.Fingerprint = fingerprint
+	return rec, false, g.store.Complete(context.WithoutCancel(ctx), key, rec, g.opts.TTL)
+}
+
+// extend renews the reservation every LockTTL/2 until the returned func is
+// called. A failed renewal is retried on the next tick.
+func (g *Guard) extend(ctx context.Context, key, fingerprint string) (stop func()) {
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(g.opts.LockTTL / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				_ = g.store.Extend(ctx, key, fingerprint, g.opts.LockTTL)
+			}
+		}
+	}()
+
+	stopped := false
+	return func() {
+		if !stopped {
+			stopped = true
+			close(done)
+			<-exited
+		}
+	}
+}
+
+// Handle wraps a queue handler so a redelivered message whose key already
+// completed is acknowledged without running fn again. A message that is
+// still being handled elsewhere returns ErrInFlight so the consumer leaves
+// it for redelivery. An empty key runs fn unguarded.
+//
+//	consumer := idempotency.Handle(guard, func(m redis.StreamMessage) string {
+//		return m.ID
+//	}, handlePayment)
+func Handle[M any](g *Guard, key func(msg M) string, fn func(ctx context.Context, msg M) error) func(ctx context.Context, msg M) error {
+	return func(ctx context.Context, msg M) error {
+		k := key(msg)
+		if k == "" {
+			return fn(ctx, msg)
+		}
+
+		_, _, err := g.Do(ctx, "queue:"+k, "", func(ctx context.Context) (Record, error) {
+			return Record{}, fn(ctx, msg)
+		})
+		return err
+	}
+}
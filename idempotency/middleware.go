@@ -0,0 +1,119 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	response "github.com/danielpnjt/go-library/basic"
+)
+
+// errNotReplayable drops the reservation for responses that must not be
+// replayed: server errors and bodies that are not a response.Response.
+var errNotReplayable = errors.New("idempotency: response not replayable")
+
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *recorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Middleware guards requests carrying the Idempotency-Key header. The first
+// request runs next and its response.Response body and status are stored;
+// duplicates get that response replayed with Idempotent-Replayed: true, and
+// duplicates arriving while the first is still running get 409. Keys are
+// scoped by method, path and Options.Principal. A SHA-256 of the body is
+// stored with the key and a duplicate with a different body gets 422. GET
+// and HEAD requests, and requests without the header, pass through. When
+// the store is unreachable the request is rejected with 503 rather than
+// risk running it twice.
+func (g *Guard) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		k := r.Header.Get(g.opts.Header)
+		if k == "" || r.Method == http.MethodGet || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeResponse(w, http.StatusBadRequest, "Bad Request")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+
+		scope := r.Method + " " + r.URL.Path
+		if g.opts.Principal != nil {
+			scope += " " + g.opts.Principal(r)
+		}
+
+		written := false
+		rec, replayed, err := g.Do(r.Context(), scope+":"+k, hex.EncodeToString(sum[:]), func(ctx context.Context) (Record, error) {
+			rw := &recorder{ResponseWriter: w}
+			written = true
+			next.ServeHTTP(rw, r.WithContext(ctx))
+
+			if rw.status == 0 {
+				rw.status = http.StatusOK
+			}
+			if rw.status >= http.StatusInternalServerError {
+				return Record{}, errNotReplayable
+			}
+			res := new(response.Response)
+			if err := json.Unmarshal(rw.body.Bytes(), res); err != nil {
+				return Record{}, errNotReplayable
+			}
+			return Record{Status: rw.status, Response: res}, nil
+		})
+
+		switch {
+		case written:
+			// next already answered; a failed Complete only loses the replay.
+		case errors.Is(err, ErrInFlight):
+			writeResponse(w, http.StatusConflict, "Request In Progress")
+		case errors.Is(err, ErrFingerprintMismatch):
+			writeResponse(w, http.StatusUnprocessableEntity, "Idempotency Key Reused")
+		case err != nil:
+			writeResponse(w, http.StatusServiceUnavailable, "Service Unavailable")
+		case replayed:
+			w.Header().Set("Idempotent-Replayed", "true")
+			writeJSON(w, rec.Status, rec.Response)
+		}
+	})
+}
+
+func writeResponse(w http.ResponseWriter, status int, desc string) {
+	writeJSON(w, status, &response.Response{
+		Data: new(struct{}),
+		Code: strconv.Itoa(status),
+		Desc: desc,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, res *response.Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(res)
+}
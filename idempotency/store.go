@@ -0,0 +1,206 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	response "github.com/danielpnjt/go-library/basic"
+	"github.com/danielpnjt/go-library/redis"
+)
+
+const (
+	defaultPrefix = "idempotency:"
+	pendingPrefix = "__pending__:"
+)
+
+var (
+	// ErrInFlight is returned by Reserve while another caller holds the key.
+	ErrInFlight = errors.New("idempotency: request already in flight")
+	// ErrFingerprintMismatch is returned by Reserve when the key was used
+	// for a request with a different fingerprint.
+	ErrFingerprintMismatch = errors.New("idempotency: key reused with a different request")
+)
+
+// Record is the final result stored for a key and replayed to duplicates.
+type Record struct {
+	Status   int                `json:"status"`
+	Response *response.Response `json:"response"`
+	// Fingerprint identifies the request that produced the record.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Store keeps reservations and completed records.
+//
+// Reserve claims key for lockTTL, remembering fingerprint with the
+// reservation. It returns (nil, nil) when the caller now holds the key, the
+// stored record when the key already completed, ErrFingerprintMismatch when
+// the key was claimed with another fingerprint and ErrInFlight when another
+// caller holds it. Extend pushes a pending reservation out to lockTTL again.
+type Store interface {
+	Reserve(ctx context.Context, key, fingerprint string, lockTTL time.Duration) (*Record, error)
+	Extend(ctx context.Context, key, fingerprint string, lockTTL time.Duration) error
+	Complete(ctx context.Context, key string, rec Record, ttl time.Duration) error
+	Release(ctx context.Context, key, fingerprint string) error
+}
+
+var reserveScript = redis.NewScript(`
+local v = redis.call("get", KEYS[1])
+if v then
+	return v
+end
+redis.call("set", KEYS[1], ARGV[1], "PX", ARGV[2])
+return ""
+`)
+
+var extendScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("pexpire", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+var releaseScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0
+`)
+
+type redisStore struct {
+	redis  *redis.RedisOop
+	prefix string
+}
+
+// NewRedisStore keeps idempotency keys on r under the "idempotency:" prefix.
+func NewRedisStore(r *redis.RedisOop) Store {
+	return &redisStore{
+		redis:  r,
+		prefix: defaultPrefix,
+	}
+}
+
+func (s *redisStore) Reserve(ctx context.Context, key, fingerprint string, lockTTL time.Duration) (*Record, error) {
+	res, err := s.redis.RunScript(ctx, reserveScript, []string{s.prefix + key}, pendingPrefix+fingerprint, lockTTL.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+
+	value, _ := res.(string)
+	if value == "" {
+		return nil, nil
+	}
+	if pending, ok := strings.CutPrefix(value, pendingPrefix); ok {
+		if pending != fingerprint {
+			return nil, ErrFingerprintMismatch
+		}
+		return nil, ErrInFlight
+	}
+
+	var rec Record
+	if err := json.Unmarshal([]byte(value), &rec); err != nil {
+		return nil, err
+	}
+	if rec.Fingerprint != fingerprint {
+		return nil, ErrFingerprintMismatch
+	}
+	return &rec, nil
+}
+
+func (s *redisStore) Extend(ctx context.Context, key, fingerprint string, lockTTL time.Duration) error {
+	_, err := s.redis.RunScript(ctx, extendScript, []string{s.prefix + key}, pendingPrefix+fingerprint, lockTTL.Milliseconds())
+	return err
+}
+
+func (s *redisStore) Complete(ctx context.Context, key string, rec Record, ttl time.Duration) error {
+	js, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return s.redis.SetRedisStringContext(ctx, s.prefix+key, string(js), ttl)
+}
+
+func (s *redisStore) Release(ctx context.Context, key, fingerprint string) error {
+	_, err := s.redis.RunScript(ctx, releaseScript, []string{s.prefix + key}, pendingPrefix+fingerprint)
+	return err
+}
+
+type memoryEntry struct {
+	record      *Record
+	fingerprint string
+	expiresAt   time.Time
+}
+
+type memoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	now     func() time.Time
+}
+
+// NewMemoryStore keeps idempotency keys in process. It is meant for tests
+// and single-instance tools; replicas do not share it.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		entries: make(map[string]memoryEntry),
+		now:     time.Now,
+	}
+}
+
+func (s *memoryStore) get(key string) (memoryEntry, bool) {
+	e, ok := s.entries[key]
+	if ok && !s.now().Before(e.expiresAt) {
+		delete(s.entries, key)
+		return memoryEntry{}, false
+	}
+	return e, ok
+}
+
+func (s *memoryStore) Reserve(ctx context.Context, key, fingerprint string, lockTTL time.Duration) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.get(key); ok {
+		if e.fingerprint != fingerprint {
+			return nil, ErrFingerprintMismatch
+		}
+		if e.record == nil {
+			return nil, ErrInFlight
+		}
+		rec := *e.record
+		return &rec, nil
+	}
+	s.entries[key] = memoryEntry{fingerprint: fingerprint, expiresAt: s.now().Add(lockTTL)}
+	return nil, nil
+}
+
+func (s *memoryStore) Extend(ctx context.Context, key, fingerprint string, lockTTL time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.get(key); ok && e.record == nil && e.fingerprint == fingerprint {
+		e.expiresAt = s.now().Add(lockTTL)
+		s.entries[key] = e
+	}
+	return nil
+}
+
+func (s *memoryStore) Complete(ctx context.Context, key string, rec Record, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = memoryEntry{record: &rec, fingerprint: rec.Fingerprint, expiresAt: s.now().Add(ttl)}
+	return nil
+}
+
+func (s *memoryStore) Release(ctx context.Context, key, fingerprint string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.get(key); ok && e.record == nil && e.fingerprint == fingerprint {
+		delete(s.entries, key)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// Closer is implemented by every client wrapper in this module.
+type Closer interface {
+	Close(ctx context.Context) error
+}
+
+// CloserFunc adapts a plain function, e.g. http.Server.Shutdown, to Closer.
+type CloserFunc func(ctx context.Context) error
+
+func (f CloserFunc) Close(ctx context.Context) error {
+	return f(ctx)
+}
+
+type closer struct {
+	name   string
+	closer Closer
+}
+
+// Manager closes registered clients in reverse registration order, so
+// clients are released after everything that depends on them.
+type Manager struct {
+	mu      sync.Mutex
+	closers []closer
+	timeout time.Duration
+	once    sync.Once
+	err     error
+}
+
+// New returns a manager that gives the whole shutdown at most timeout.
+func New(timeout time.Duration) *Manager {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &Manager{
+		timeout: timeout,
+	}
+}
+
+func (m *Manager) Register(name string, c Closer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closers = append(m.closers, closer{name: name, closer: c})
+}
+
+// Shutdown closes every registered client once, in reverse order, and joins
+// their errors. Later calls return the result of the first.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.once.Do(func() {
+		ctx, cancel := context.WithTimeout(ctx, m.timeout)
+		defer cancel()
+
+		m.mu.Lock()
+		closers := append([]closer(nil), m.closers...)
+		m.mu.Unlock()
+
+		var errs []error
+		for i := len(closers) - 1; i >= 0; i-- {
+			c := closers[i]
+			if err := c.closer.Close(ctx); err != nil {
+				fmt.Println("error on closing", c.name, ":", err)
+				errs = append(errs, fmt.Errorf("close %s: %w", c.name, err))
+			}
+		}
+		m.err = errors.Join(errs...)
+	})
+	return m.err
+}
+
+// Wait blocks until SIGTERM or SIGINT arrives, or ctx is done, then runs
+// Shutdown.
+func (m *Manager) Wait(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	<-ctx.Done()
+	fmt.Println("shutting down:", ctx.Err())
+
+	return m.Shutdown(context.Background())
+}
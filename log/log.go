@@ -106,6 +106,12 @@ func LogDebug(msg string) {
 	logText.Debug(fmt.Sprintf("%s [%s] %s", timestamp, "", msg))
 }
 
+// LogWarn writes msg with fields as one JSON line at warning level.
+func LogWarn(msg string, fields map[string]interface{}) {
+	timestamp := SetLogFile(0)
+	logJSON.WithFields(logrus.Fields(fields)).WithField("timestamp", timestamp).Warn(msg)
+}
+
 func Minify(r interface{}) map[string]interface{} {
 	js, _ := json.Marshal(r)
 	var m map[string]interface{}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"time"
+)
+
+const (
+	ComponentHttp     = "http"
+	ComponentMinio    = "minio"
+	ComponentRedis    = "redis"
+	ComponentMysql    = "mysql"
+	ComponentPostgres = "postgresql"
+	ComponentSftp     = "sftp"
+)
+
+// Recorder receives one observation for every call made by a client wrapper.
+type Recorder interface {
+	ObserveRequest(component, operation, target string, elapsed time.Duration, err error)
+}
+
+type noopRecorder struct{}
+
+func (noopRecorder) ObserveRequest(component, operation, target string, elapsed time.Duration, err error) {
+}
+
+var (
+	recorder Recorder = noopRecorder{}
+)
+
+// Init enables metrics for every wrapper in this module. Metrics are off
+// until it is called.
+func Init(r Recorder) {
+	if r == nil {
+		r = noopRecorder{}
+	}
+	recorder = r
+}
+
+// Observe records a call that started at start and finished now.
+func Observe(component, operation, target string, start time.Time, err error) {
+	recorder.ObserveRequest(component, operation, target, time.Since(start), err)
+}
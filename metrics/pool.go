@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolStats is the subset of pool statistics shared by database/sql and pgxpool.
+type poolStats struct {
+	open         float64
+	maxOpen      float64
+	inUse        float64
+	idle         float64
+	waitCount    float64
+	waitDuration float64
+}
+
+// poolCollector reads pool statistics once per scrape.
+type poolCollector struct {
+	open         *prometheus.Desc
+	maxOpen      *prometheus.Desc
+	inUse        *prometheus.Desc
+	idle         *prometheus.Desc
+	waitCount    *prometheus.Desc
+	waitDuration *prometheus.Desc
+	read         func() poolStats
+}
+
+func newPoolCollector(namespace, pool string, read func() poolStats) *poolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "db_pool", name),
+			help,
+			nil,
+			prometheus.Labels{"pool": pool},
+		)
+	}
+
+	return &poolCollector{
+		open:         desc("open_connections", "Established connections, in use or idle."),
+		maxOpen:      desc("max_open_connections", "Maximum number of open connections."),
+		inUse:        desc("in_use_connections", "Connections currently in use."),
+		idle:         desc("idle_connections", "Idle connections."),
+		waitCount:    desc("wait_count_total", "Total number of connections waited for."),
+		waitDuration: desc("wait_duration_seconds_total", "Total time blocked waiting for a connection."),
+		read:         read,
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.open
+	ch <- c.maxOpen
+	ch <- c.inUse
+	ch <- c.idle
+	ch <- c.waitCount
+	ch <- c.waitDuration
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.read()
+	ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, s.open)
+	ch <- prometheus.MustNewConstMetric(c.maxOpen, prometheus.GaugeValue, s.maxOpen)
+	ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, s.inUse)
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, s.idle)
+	ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, s.waitCount)
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, s.waitDuration)
+}
+
+// NewSQLStatsCollector exports sql.DBStats, e.g. of MysqlOop.DB.DB, labeled
+// with pool. Register it on the same registry passed to NewPrometheus.
+func NewSQLStatsCollector(namespace, pool string, db *sql.DB) prometheus.Collector {
+	return newPoolCollector(namespace, pool, func() poolStats {
+		s := db.Stats()
+		return poolStats{
+			open:         float64(s.OpenConnections),
+			maxOpen:      float64(s.MaxOpenConnections),
+			inUse:        float64(s.InUse),
+			idle:         float64(s.Idle),
+			waitCount:    float64(s.WaitCount),
+			waitDuration: s.WaitDuration.Seconds(),
+		}
+	})
+}
+
+// NewPgxPoolStatsCollector exports pgxpool.Stat, e.g. of PostgresOop.DB,
+// labeled with pool.
+func NewPgxPoolStatsCollector(namespace, pool string, p *pgxpool.Pool) prometheus.Collector {
+	return newPoolCollector(namespace, pool, func() poolStats {
+		s := p.Stat()
+		return poolStats{
+			open:         float64(s.TotalConns()),
+			maxOpen:      float64(s.MaxConns()),
+			inUse:        float64(s.AcquiredConns()),
+			idle:         float64(s.IdleConns()),
+			waitCount:    float64(s.EmptyAcquireCount()),
+			waitDuration: s.AcquireDuration().Seconds(),
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var labels = []string{"component", "operation", "target"}
+
+// Prometheus is a Recorder exporting request, error and latency series.
+type Prometheus struct {
+	requests *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+}
+
+// NewPrometheus registers the client series on reg. Pass a dedicated
+// prometheus.NewRegistry() to stay off the global default registry.
+func NewPrometheus(reg prometheus.Registerer, namespace string) (*Prometheus, error) {
+	p := &Prometheus{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "client",
+			Name:      "requests_total",
+			Help:      "Number of calls made by a client wrapper.",
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "client",
+			Name:      "errors_total",
+			Help:      "Number of calls made by a client wrapper that returned an error.",
+		}, labels),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "client",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of calls made by a client wrapper.",
+			Buckets:   prometheus.DefBuckets,
+		}, labels),
+	}
+
+	for _, c := range []prometheus.Collector{p.requests, p.errors, p.latency} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+func (p *Prometheus) ObserveRequest(component, operation, target string, elapsed time.Duration, err error) {
+	p.requests.WithLabelValues(component, operation, target).Inc()
+	if err != nil {
+		p.errors.WithLabelValues(component, operation, target).Inc()
+	}
+	p.latency.WithLabelValues(component, operation, target).Observe(elapsed.Seconds())
+}
@@ -0,0 +1,289 @@
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultTable       = "schema_migrations"
+	defaultLockTimeout = time.Minute
+)
+
+var (
+	ErrLockTimeout = errors.New("migrate: timed out waiting for the migration lock")
+	ErrChecksum    = errors.New("migrate: applied migration was modified")
+	ErrMissing     = errors.New("migrate: applied migration has no file")
+	ErrNoDown      = errors.New("migrate: migration has no down file")
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration is one version read from the migrations directory.
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// Status is a migration together with whether it has been applied.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type Options struct {
+	// Table records applied versions. Defaults to "schema_migrations".
+	Table string
+	// LockName is the advisory lock key. Defaults to Table.
+	LockName string
+	// LockTimeout bounds the wait for another replica to finish migrating.
+	// Defaults to one minute.
+	LockTimeout time.Duration
+	// DryRun reports what Up and Down would run without changing the
+	// database: the version table is only read, and is not created when
+	// missing.
+	DryRun bool
+}
+
+func (o *Options) defaults() {
+	if o.Table == "" {
+		o.Table = defaultTable
+	}
+	if o.LockName == "" {
+		o.LockName = o.Table
+	}
+	if o.LockTimeout <= 0 {
+		o.LockTimeout = defaultLockTimeout
+	}
+}
+
+// record is a row of the version table.
+type record struct {
+	version   int64
+	checksum  string
+	appliedAt time.Time
+}
+
+// conn is a dedicated connection holding the advisory lock.
+type conn interface {
+	tableExists(ctx context.Context) (bool, error)
+	ensureTable(ctx context.Context) error
+	applied(ctx context.Context) ([]record, error)
+	up(ctx context.Context, m Migration) error
+	down(ctx context.Context, m Migration) error
+	release()
+}
+
+// Migrator applies the migrations of one directory to one database.
+type Migrator struct {
+	open       func(ctx context.Context) (conn, error)
+	migrations []Migration
+	opts       Options
+}
+
+// Load reads <version>_<name>.up.sql and <version>_<name>.down.sql files
+// from the root of fsys. Use fs.Sub to point it at a subdirectory of an
+// embed.FS.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migrate: %s: %w", entry.Name(), err)
+		}
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migrate: version %d is used by %q and %q", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+			sum := sha256.Sum256(body)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Checksum == "" {
+			return nil, fmt.Errorf("migrate: version %d has no up file", m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+func newMigrator(fsys fs.FS, opts Options, open func(ctx context.Context, opts Options) (conn, error)) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	opts.defaults()
+
+	return &Migrator{
+		open: func(ctx context.Context) (conn, error) {
+			return open(ctx, opts)
+		},
+		migrations: migrations,
+		opts:       opts,
+	}, nil
+}
+
+// state locks the database and returns the applied records by version
+// after checking them against the files.
+func (m *Migrator) state(ctx context.Context) (conn, map[int64]record, error) {
+	c, err := m.open(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	records, err := m.records(ctx, c)
+	if err != nil {
+		c.release()
+		return nil, nil, err
+	}
+
+	files := make(map[int64]Migration, len(m.migrations))
+	for _, mig := range m.migrations {
+		files[mig.Version] = mig
+	}
+
+	applied := make(map[int64]record, len(records))
+	for _, rec := range records {
+		mig, ok := files[rec.version]
+		if !ok {
+			c.release()
+			return nil, nil, fmt.Errorf("%w: version %d", ErrMissing, rec.version)
+		}
+		if mig.Checksum != rec.checksum {
+			c.release()
+			return nil, nil, fmt.Errorf("%w: version %d (%s)", ErrChecksum, rec.version, mig.Name)
+		}
+		applied[rec.version] = rec
+	}
+	return c, applied, nil
+}
+
+// records reads the version table, creating it first unless DryRun is set.
+// In a dry run a missing table means nothing has been applied yet.
+func (m *Migrator) records(ctx context.Context, c conn) ([]record, error) {
+	if m.opts.DryRun {
+		exists, err := c.tableExists(ctx)
+		if err != nil || !exists {
+			return nil, err
+		}
+	} else if err := c.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	return c.applied(ctx)
+}
+
+// Up applies every pending migration in version order and returns them.
+// With DryRun it only returns them.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	c, applied, err := m.state(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer c.release()
+
+	var done []Migration
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+		if !m.opts.DryRun {
+			if err := c.up(ctx, mig); err != nil {
+				return done, fmt.Errorf("migrate: up %d (%s): %w", mig.Version, mig.Name, err)
+			}
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// Down rolls back the last steps applied migrations, newest first, and
+// returns them. With DryRun it only returns them.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	c, applied, err := m.state(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer c.release()
+
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		mig := m.migrations[i]
+		if _, ok := applied[mig.Version]; !ok {
+			continue
+		}
+		if mig.Down == "" {
+			return done, fmt.Errorf("%w: version %d (%s)", ErrNoDown, mig.Version, mig.Name)
+		}
+		if !m.opts.DryRun {
+			if err := c.down(ctx, mig); err != nil {
+				return done, fmt.Errorf("migrate: down %d (%s): %w", mig.Version, mig.Name, err)
+			}
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// Status lists every migration file and whether it has been applied. It
+// waits for the lock like Up so it never reports a half-finished run.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	c, applied, err := m.state(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer c.release()
+
+	status := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		rec, ok := applied[mig.Version]
+		status = append(status, Status{
+			Migration: mig,
+			Applied:   ok,
+			AppliedAt: rec.appliedAt,
+		})
+	}
+	return status, nil
+}
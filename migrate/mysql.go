@@ -0,0 +1,197 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io/fs"
+	"strings"
+
+	"github.com/danielpnjt/go-library/mysql"
+)
+
+// NewMysql migrates r. Each file may hold several statements separated by
+// semicolons; DELIMITER blocks are not supported. MySQL commits DDL
+// implicitly, so a file that fails halfway is not rolled back.
+func NewMysql(r *mysql.MysqlOop, fsys fs.FS, opts Options) (*Migrator, error) {
+	return newMigrator(fsys, opts, func(ctx context.Context, opts Options) (conn, error) {
+		return openMysql(ctx, r.DB.DB, opts)
+	})
+}
+
+type mysqlConn struct {
+	conn *sql.Conn
+	opts Options
+}
+
+func openMysql(ctx context.Context, db *sql.DB, opts Options) (conn, error) {
+	c, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var got sql.NullInt64
+	err = c.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", opts.LockName, int(opts.LockTimeout.Seconds())).Scan(&got)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	if got.Int64 != 1 {
+		c.Close()
+		return nil, ErrLockTimeout
+	}
+
+	return &mysqlConn{
+		conn: c,
+		opts: opts,
+	}, nil
+}
+
+func (c *mysqlConn) table() string {
+	return "`" + strings.ReplaceAll(c.opts.Table, "`", "``") + "`"
+}
+
+func (c *mysqlConn) tableExists(ctx context.Context) (bool, error) {
+	var n int
+	err := c.conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?", c.opts.Table).Scan(&n)
+	return n > 0, err
+}
+
+func (c *mysqlConn) ensureTable(ctx context.Context) error {
+	_, err := c.conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+c.table()+` (
+		version BIGINT NOT NULL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		checksum CHAR(64) NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	return err
+}
+
+func (c *mysqlConn) applied(ctx context.Context) ([]record, error) {
+	rows, err := c.conn.QueryContext(ctx, "SELECT version, checksum, applied_at FROM "+c.table()+" ORDER BY version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []record
+	for rows.Next() {
+		var rec record
+		if err := rows.Scan(&rec.version, &rec.checksum, &rec.appliedAt); err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+func (c *mysqlConn) run(ctx context.Context, script string, record string, args ...interface{}) error {
+	tx, err := c.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range splitStatements(script) {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (c *mysqlConn) up(ctx context.Context, m Migration) error {
+	return c.run(ctx, m.Up, "INSERT INTO "+c.table()+" (version, name, checksum) VALUES (?, ?, ?)", m.Version, m.Name, m.Checksum)
+}
+
+func (c *mysqlConn) down(ctx context.Context, m Migration) error {
+	return c.run(ctx, m.Down, "DELETE FROM "+c.table()+" WHERE version = ?", m.Version)
+}
+
+func (c *mysqlConn) release() {
+	// The lock belongs to the session. If RELEASE_LOCK fails the connection
+	// is discarded rather than returned to the pool still holding it.
+	_, err := c.conn.ExecContext(context.Background(), "DO RELEASE_LOCK(?)", c.opts.LockName)
+	if err != nil {
+		c.conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	}
+	c.conn.Close()
+}
+
+// splitStatements splits a script on semicolons outside quotes and comments.
+func splitStatements(script string) []string {
+	var (
+		stmts []string
+		start int
+		quote byte
+	)
+	for i := 0; i < len(script); i++ {
+		ch := script[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' && quote != '`' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"' || ch == '`':
+			quote = ch
+		case ch == '#' || (ch == '-' && strings.HasPrefix(script[i:], "-- ")):
+			if end := strings.IndexByte(script[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(script)
+			}
+		case ch == '/' && strings.HasPrefix(script[i:], "/*"):
+			if end := strings.Index(script[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(script)
+			}
+		case ch == ';':
+			stmts = appendStatement(stmts, script[start:i])
+			start = i + 1
+		}
+	}
+	if start < len(script) {
+		stmts = appendStatement(stmts, script[start:])
+	}
+	return stmts
+}
+
+func appendStatement(stmts []string, stmt string) []string {
+	stmt = strings.TrimSpace(stmt)
+	if stmt == "" || isComment(stmt) {
+		return stmts
+	}
+	return append(stmts, stmt)
+}
+
+// isComment reports whether stmt holds nothing but comments. Versioned
+// /*! ... */ comments are executed by MySQL and do not count.
+func isComment(stmt string) bool {
+	for {
+		stmt = strings.TrimSpace(stmt)
+		switch {
+		case stmt == "":
+			return true
+		case strings.HasPrefix(stmt, "--") || strings.HasPrefix(stmt, "#"):
+			end := strings.IndexByte(stmt, '\n')
+			if end < 0 {
+				return true
+			}
+			stmt = stmt[end:]
+		case strings.HasPrefix(stmt, "/*") && !strings.HasPrefix(stmt, "/*!"):
+			end := strings.Index(stmt, "*/")
+			if end < 0 {
+				return true
+			}
+			stmt = stmt[end+2:]
+		default:
+			return false
+		}
+	}
+}
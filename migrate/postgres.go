@@ -0,0 +1,131 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"io/fs"
+	"strings"
+
+	"github.com/danielpnjt/go-library/postgresql"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// NewPostgres migrates r. Each file runs in its own transaction together
+// with its version row, so a failing file leaves no trace.
+func NewPostgres(r *postgresql.PostgresOop, fsys fs.FS, opts Options) (*Migrator, error) {
+	return newMigrator(fsys, opts, func(ctx context.Context, opts Options) (conn, error) {
+		return openPostgres(ctx, r.DB, opts)
+	})
+}
+
+type postgresConn struct {
+	conn *pgxpool.Conn
+	key  int64
+	opts Options
+}
+
+// lockKey maps the lock name onto the int64 key space of pg_advisory_lock.
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
+
+func openPostgres(ctx context.Context, pool *pgxpool.Pool, opts Options) (conn, error) {
+	c, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key := lockKey(opts.LockName)
+	lockCtx, cancel := context.WithTimeout(ctx, opts.LockTimeout)
+	defer cancel()
+
+	if _, err := c.Exec(lockCtx, "SELECT pg_advisory_lock($1)", key); err != nil {
+		c.Release()
+		if errors.Is(lockCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			return nil, ErrLockTimeout
+		}
+		return nil, err
+	}
+
+	return &postgresConn{
+		conn: c,
+		key:  key,
+		opts: opts,
+	}, nil
+}
+
+func (c *postgresConn) table() string {
+	return `"` + strings.ReplaceAll(c.opts.Table, `"`, `""`) + `"`
+}
+
+func (c *postgresConn) tableExists(ctx context.Context) (bool, error) {
+	var exists bool
+	err := c.conn.QueryRow(ctx, "SELECT to_regclass($1) IS NOT NULL", c.table()).Scan(&exists)
+	return exists, err
+}
+
+func (c *postgresConn) ensureTable(ctx context.Context) error {
+	_, err := c.conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS `+c.table()+` (
+		version BIGINT NOT NULL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		checksum CHAR(64) NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	return err
+}
+
+func (c *postgresConn) applied(ctx context.Context) ([]record, error) {
+	rows, err := c.conn.Query(ctx, "SELECT version, checksum, applied_at FROM "+c.table()+" ORDER BY version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []record
+	for rows.Next() {
+		var rec record
+		if err := rows.Scan(&rec.version, &rec.checksum, &rec.appliedAt); err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+func (c *postgresConn) run(ctx context.Context, script string, record string, args ...interface{}) error {
+	tx, err := c.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Exec without arguments uses the simple protocol, which accepts
+	// several statements in one call.
+	if _, err := tx.Exec(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (c *postgresConn) up(ctx context.Context, m Migration) error {
+	return c.run(ctx, m.Up, "INSERT INTO "+c.table()+" (version, name, checksum) VALUES ($1, $2, $3)", m.Version, m.Name, m.Checksum)
+}
+
+func (c *postgresConn) down(ctx context.Context, m Migration) error {
+	return c.run(ctx, m.Down, "DELETE FROM "+c.table()+" WHERE version = $1", m.Version)
+}
+
+func (c *postgresConn) release() {
+	// The lock belongs to the session. If unlocking fails the connection is
+	// closed rather than returned to the pool still holding it.
+	if _, err := c.conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", c.key); err != nil {
+		c.conn.Conn().Close(context.Background())
+	}
+	c.conn.Release()
+}
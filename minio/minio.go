@@ -8,22 +8,19 @@ import (
 	"time"
 
 	"github.com/danielpnjt/go-library/contextwrap"
+	"github.com/danielpnjt/go-library/metrics"
+	"github.com/danielpnjt/go-library/tracing"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
-	"go.elastic.co/apm"
 )
 
 type MinioOop struct {
-	minioClient *minio.Client
-	endpoint    string
+	minioClient  *minio.Client
+	endpoint     string
+	healthBucket string
 }
 
-type TraceMinio struct {
-	Host       string `json:"host"`
-	ObjectName string `json:"object_name"`
-	BucketName string `json:"bucket_name"`
-	Elapsed    string `json:"elapsed"`
-}
+type TraceMinio = contextwrap.TraceMinio
 
 func Init(id string, secret string, token string, isSecure bool, endpointMinio string) (*MinioOop, error) {
 	opts := &minio.Options{
@@ -43,102 +40,126 @@ func Init(id string, secret string, token string, isSecure bool, endpointMinio s
 	return minclient, nil
 }
 
+func (m *MinioOop) observe(operation string, bucketName string, start time.Time, err error) {
+	metrics.Observe(metrics.ComponentMinio, operation, bucketName, start, err)
+}
+
 func (m *MinioOop) PutObject(ctx context.Context, bucketName string, objectName string, objectBase64 []byte, objectSize int64, contentType string) (minio.UploadInfo, error) {
-	apmSpan, _ := apm.StartSpan(ctx, "PutObject", "Minio")
-	defer apmSpan.End()
+	start := time.Now()
+	_, span := tracing.StartSpan(ctx, "PutObject", "Minio")
+	defer span.End()
 
 	uploadInfo, err := m.minioClient.PutObject(ctx, bucketName, objectName, bytes.NewReader(objectBase64), objectSize, minio.PutObjectOptions{ContentType: contentType})
 	if err != nil {
+		span.RecordError(err)
 		fmt.Println("put object minio error : ", err)
 	}
+
+	m.observe("PutObject", bucketName, start, err)
 	return uploadInfo, err
 }
 
 func (m *MinioOop) GetObject(ctx context.Context, bucketName string, objectName string) (*minio.Object, error) {
-	apmSpan, _ := apm.StartSpan(ctx, "GetObject", "Minio")
-	defer apmSpan.End()
+	start := time.Now()
+	_, span := tracing.StartSpan(ctx, "GetObject", "Minio")
+	defer span.End()
 
 	minioObj, err := m.minioClient.GetObject(ctx, bucketName, objectName, minio.GetObjectOptions{})
 	if err != nil {
+		span.RecordError(err)
 		fmt.Println("get object minio error : ", err)
 	}
+
+	m.observe("GetObject", bucketName, start, err)
 	return minioObj, err
 }
 
 func (m *MinioOop) FGetObject(ctx context.Context, bucketName, objectName, filepath string) (context.Context, error) {
 	start := time.Now()
-	apmSpan, _ := apm.StartSpan(ctx, "FGetObject", "Minio")
-	defer apmSpan.End()
+	_, span := tracing.StartSpan(ctx, "FGetObject", "Minio")
+	defer span.End()
 
-	trOri := contextwrap.GetTraceFromContext(ctx)
 	err := m.minioClient.FGetObject(ctx, bucketName, objectName, filepath, minio.GetObjectOptions{})
 	if err != nil {
+		span.RecordError(err)
 		fmt.Println("get object minio error : ", err)
 	}
 
 	tr := &TraceMinio{
+		TraceMeta:  contextwrap.NewTraceMeta(start, err),
 		Host:       m.endpoint,
+		Operation:  "FGetObject",
 		ObjectName: objectName,
 		BucketName: bucketName,
-		Elapsed:    time.Since(start).String(),
 	}
+	tr.Elapsed = tr.Took.String()
 
-	trProcessed := append(trOri, tr)
-	ctx = contextwrap.SetTraceFromContext(ctx, trProcessed)
+	ctx = contextwrap.AppendTrace(ctx, tr)
 
+	m.observe("FGetObject", bucketName, start, err)
 	return ctx, err
 }
 
 func (m *MinioOop) FPutObject(ctx context.Context, bucketName, objectName, filepath string, contentType string) (context.Context, minio.UploadInfo, error) {
 	start := time.Now()
-	apmSpan, _ := apm.StartSpan(ctx, "FPutObject", "Minio")
-	defer apmSpan.End()
+	_, span := tracing.StartSpan(ctx, "FPutObject", "Minio")
+	defer span.End()
 
-	trOri := contextwrap.GetTraceFromContext(ctx)
 	uploadInfo, err := m.minioClient.FPutObject(ctx, bucketName, objectName, filepath, minio.PutObjectOptions{ContentType: contentType})
 	if err != nil {
+		span.RecordError(err)
 		fmt.Println("put object minio error : ", err)
 	}
 
 	tr := &TraceMinio{
+		TraceMeta:  contextwrap.NewTraceMeta(start, err),
 		Host:       m.endpoint,
+		Operation:  "FPutObject",
 		ObjectName: objectName,
 		BucketName: bucketName,
-		Elapsed:    time.Since(start).String(),
 	}
+	tr.Elapsed = tr.Took.String()
 
-	trProcessed := append(trOri, tr)
-	ctx = contextwrap.SetTraceFromContext(ctx, trProcessed)
+	ctx = contextwrap.AppendTrace(ctx, tr)
 
+	m.observe("FPutObject", bucketName, start, err)
 	return ctx, uploadInfo, err
 }
 
 func (m *MinioOop) StatObject(ctx context.Context, bucketName string, objectName string) (minio.ObjectInfo, error) {
-	apmSpan, _ := apm.StartSpan(ctx, "StatObject", "Minio")
-	defer apmSpan.End()
+	start := time.Now()
+	_, span := tracing.StartSpan(ctx, "StatObject", "Minio")
+	defer span.End()
 
 	minioObj, err := m.minioClient.StatObject(ctx, bucketName, objectName, minio.StatObjectOptions{})
 	if err != nil {
+		span.RecordError(err)
 		fmt.Println("get object minio error :", err)
 	}
+
+	m.observe("StatObject", bucketName, start, err)
 	return minioObj, err
 }
 
 func (m *MinioOop) BucketExists(ctx context.Context, bucketName string) (bool, error) {
-	apmSpan, _ := apm.StartSpan(ctx, "BucketExists", "Minio")
-	defer apmSpan.End()
+	start := time.Now()
+	_, span := tracing.StartSpan(ctx, "BucketExists", "Minio")
+	defer span.End()
 
 	isExist, err := m.minioClient.BucketExists(ctx, bucketName)
 	if err != nil {
+		span.RecordError(err)
 		fmt.Println("check existence of bucket get error : ", err)
 	}
 
+	m.observe("BucketExists", bucketName, start, err)
 	return isExist, err
 }
 
 func (m *MinioOop) ListObjects(ctx context.Context, bucketName, folderName string) (context.Context, <-chan minio.ObjectInfo) {
-	apmSpan, _ := apm.StartSpan(ctx, "ListObjects", "Minio")
-	defer apmSpan.End()
+	start := time.Now()
+	_, span := tracing.StartSpan(ctx, "ListObjects", "Minio")
+	defer span.End()
 
 	listObjects := m.minioClient.ListObjects(context.Background(), bucketName, minio.ListObjectsOptions{
 		Prefix:    folderName,
@@ -149,24 +170,29 @@ func (m *MinioOop) ListObjects(ctx context.Context, bucketName, folderName strin
 		fmt.Println(fmt.Printf("no object exist inside bucket %v folder %v", bucketName, folderName))
 	}
 
+	m.observe("ListObjects", bucketName, start, nil)
 	return ctx, listObjects
 }
 
 func (m *MinioOop) RemoveObject(ctx context.Context, bucketName string, object minio.ObjectInfo) (context.Context, error) {
-	apmSpan, _ := apm.StartSpan(ctx, "RemoveObject", "Minio")
-	defer apmSpan.End()
+	start := time.Now()
+	_, span := tracing.StartSpan(ctx, "RemoveObject", "Minio")
+	defer span.End()
 
 	err := m.minioClient.RemoveObject(ctx, bucketName, object.Key, minio.RemoveObjectOptions{})
 	if err != nil {
+		span.RecordError(err)
 		fmt.Println(fmt.Printf("failed remove object %v, cause : %v", object.Key, err.Error()))
 	}
 
+	m.observe("RemoveObject", bucketName, start, err)
 	return ctx, err
 }
 
 func (m *MinioOop) CopyObject(ctx context.Context, objectName, destination, source string) (context.Context, minio.UploadInfo, error) {
-	apmSpan, _ := apm.StartSpan(ctx, "CopyObject", "Minio")
-	defer apmSpan.End()
+	start := time.Now()
+	_, span := tracing.StartSpan(ctx, "CopyObject", "Minio")
+	defer span.End()
 
 	info, err := m.minioClient.CopyObject(ctx,
 		minio.CopyDestOptions{
@@ -177,59 +203,105 @@ func (m *MinioOop) CopyObject(ctx context.Context, objectName, destination, sour
 			Object: objectName,
 		})
 	if err != nil {
+		span.RecordError(err)
 		fmt.Println(fmt.Printf("failed copy object %v, cause : %v", objectName, err.Error()))
 	}
 
+	m.observe("CopyObject", destination, start, err)
 	return ctx, info, err
 }
 
 func (m *MinioOop) RemoveObjectWithBypassGovernance(ctx context.Context, bucketName string, object minio.ObjectInfo) (context.Context, error) {
-	apmSpan, _ := apm.StartSpan(ctx, "RemoveObjectWithBypassGovernance", "Minio")
-	defer apmSpan.End()
+	start := time.Now()
+	_, span := tracing.StartSpan(ctx, "RemoveObjectWithBypassGovernance", "Minio")
+	defer span.End()
 
 	err := m.minioClient.RemoveObject(ctx, bucketName, object.Key, minio.RemoveObjectOptions{
 		GovernanceBypass: true,
 	})
 
 	if err != nil {
+		span.RecordError(err)
 		fmt.Println(fmt.Printf("failed remove object %v, cause : %v", object.Key, err.Error()))
 	}
 
+	m.observe("RemoveObjectWithBypassGovernance", bucketName, start, err)
 	return ctx, err
 }
 
 func (m *MinioOop) PresignedPutObject(ctx context.Context, bucketName, objectName string, expires time.Duration) (*url.URL, error) {
-	apmSpan, _ := apm.StartSpan(ctx, "PresignedPutObject", "Minio")
-	defer apmSpan.End()
+	start := time.Now()
+	_, span := tracing.StartSpan(ctx, "PresignedPutObject", "Minio")
+	defer span.End()
 
 	uploadUrl, err := m.minioClient.PresignedPutObject(ctx, bucketName, objectName, expires)
 	if err != nil {
+		span.RecordError(err)
 		fmt.Println("generate presigned url put object minio error : ", err)
 	}
+
+	m.observe("PresignedPutObject", bucketName, start, err)
 	return uploadUrl, err
 }
 
 func (m *MinioOop) PresignedGetObject(ctx context.Context, bucketName, objectName string, expires time.Duration, reqParams url.Values) (*url.URL, error) {
-	apmSpan, _ := apm.StartSpan(ctx, "PresignedGetObject", "Minio")
-	defer apmSpan.End()
+	start := time.Now()
+	_, span := tracing.StartSpan(ctx, "PresignedGetObject", "Minio")
+	defer span.End()
 
 	downloadUrl, err := m.minioClient.PresignedGetObject(ctx, bucketName, objectName, expires, reqParams)
 	if err != nil {
+		span.RecordError(err)
 		fmt.Println("generate presigned url get object minio error : ", err)
 	}
+
+	m.observe("PresignedGetObject", bucketName, start, err)
 	return downloadUrl, err
 }
 
 func (m *MinioOop) ForceRemoveObject(ctx context.Context, bucketName string, object minio.ObjectInfo) (context.Context, error) {
-	apmSpan, _ := apm.StartSpan(ctx, "RemoveObject", "Minio")
-	defer apmSpan.End()
+	start := time.Now()
+	_, span := tracing.StartSpan(ctx, "RemoveObject", "Minio")
+	defer span.End()
 
 	err := m.minioClient.RemoveObject(ctx, bucketName, object.Key, minio.RemoveObjectOptions{
 		ForceDelete: true,
 	})
 	if err != nil {
+		span.RecordError(err)
 		fmt.Println(fmt.Printf("failed remove object %v, cause : %v", object.Key, err.Error()))
 	}
 
+	m.observe("ForceRemoveObject", bucketName, start, err)
 	return ctx, err
 }
+
+// SetHealthCheckBucket makes HealthCheck verify bucketName exists instead of
+// listing every bucket.
+func (m *MinioOop) SetHealthCheckBucket(bucketName string) {
+	m.healthBucket = bucketName
+}
+
+// HealthCheck verifies the configured bucket exists, or that buckets can be
+// listed when none is configured.
+func (m *MinioOop) HealthCheck(ctx context.Context) error {
+	if m.healthBucket == "" {
+		_, err := m.minioClient.ListBuckets(ctx)
+		return err
+	}
+
+	exists, err := m.minioClient.BucketExists(ctx, m.healthBucket)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("bucket %s does not exist", m.healthBucket)
+	}
+	return nil
+}
+
+// Close is a no-op kept for symmetry with the other clients; the minio
+// client holds no connection beyond the shared HTTP transport.
+func (m *MinioOop) Close(ctx context.Context) error {
+	return nil
+}
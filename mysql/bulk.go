@@ -0,0 +1,172 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/danielpnjt/go-library/dberr"
+	"github.com/danielpnjt/go-library/sqlbuilder"
+)
+
+// MySQL caps a prepared statement at 65535 placeholders.
+const maxPlaceholders = 65535
+
+// RowSource yields the rows of a bulk insert one at a time. It has the
+// shape of pgx.CopyFromSource so one source works with both wrappers.
+type RowSource interface {
+	Next() bool
+	Values() ([]interface{}, error)
+	Err() error
+}
+
+type sliceRows struct {
+	rows [][]interface{}
+	i    int
+}
+
+// SliceRows is a RowSource over rows held in memory.
+func SliceRows(rows [][]interface{}) RowSource {
+	return &sliceRows{rows: rows, i: -1}
+}
+
+func (s *sliceRows) Next() bool {
+	s.i++
+	return s.i < len(s.rows)
+}
+
+func (s *sliceRows) Values() ([]interface{}, error) {
+	return s.rows[s.i], nil
+}
+
+func (s *sliceRows) Err() error {
+	return nil
+}
+
+// BulkProgress is reported after every chunk.
+type BulkProgress struct {
+	Chunk int
+	Rows  int
+	Total int64
+}
+
+type BulkOptions struct {
+	// MaxRows caps the rows per chunk. Chunks are otherwise sized to fit
+	// max_allowed_packet and the placeholder limit.
+	MaxRows int
+	// Progress is called after each chunk is written.
+	Progress func(p BulkProgress)
+}
+
+// BulkInsert writes rows into table with multi-row INSERT statements on the
+// primary. Chunks are committed one by one: on error the rows of earlier
+// chunks stay inserted and the returned count says how many there were.
+func (r *MysqlOop) BulkInsert(ctx context.Context, table string, columns []string, rows RowSource, opts BulkOptions) (total int64, err error) {
+	ctx, q := r.startQuery(ctx, "BulkInsert", "INSERT INTO "+table)
+	defer func() {
+		q.rows = total
+		q.end(err)
+	}()
+
+	if len(columns) == 0 {
+		return 0, errors.New("bulk insert failed: no columns")
+	}
+
+	var maxPacket int
+	if err := r.DB.GetContext(ctx, &maxPacket, "SELECT @@max_allowed_packet"); err != nil {
+		return 0, fmt.Errorf("bulk insert failed: %w", dberr.Classify(err))
+	}
+	// Leave room for the protocol framing and the size estimate being off.
+	budget := maxPacket * 9 / 10
+
+	maxRows := maxPlaceholders / len(columns)
+	if opts.MaxRows > 0 && opts.MaxRows < maxRows {
+		maxRows = opts.MaxRows
+	}
+
+	var (
+		chunk [][]interface{}
+		size  int
+		n     int
+	)
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+
+		b := sqlbuilder.MySQL.InsertInto(table).Columns(columns...)
+		for _, row := range chunk {
+			b.Values(row...)
+		}
+		stmt, args, err := b.ToSQL()
+		if err != nil {
+			return err
+		}
+		if _, err := r.DB.ExecContext(ctx, stmt, args...); err != nil {
+			return dberr.Classify(err)
+		}
+
+		n++
+		total += int64(len(chunk))
+		if opts.Progress != nil {
+			opts.Progress(BulkProgress{Chunk: n, Rows: len(chunk), Total: total})
+		}
+		chunk, size = chunk[:0], 0
+		return nil
+	}
+
+	// The statement text repeats the column list once.
+	header := len(table) + 32
+	for _, c := range columns {
+		header += len(c) + 4
+	}
+
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return total, fmt.Errorf("bulk insert failed: %w", err)
+		}
+		if len(values) != len(columns) {
+			return total, fmt.Errorf("bulk insert failed: row has %d values for %d columns", len(values), len(columns))
+		}
+
+		rowSize := rowSize(values)
+		if len(chunk) > 0 && (len(chunk) >= maxRows || header+size+rowSize > budget) {
+			if err := flush(); err != nil {
+				return total, fmt.Errorf("bulk insert failed: %w", err)
+			}
+		}
+		// Sources may reuse the slice Values returns.
+		chunk = append(chunk, append([]interface{}(nil), values...))
+		size += rowSize
+	}
+	if err := rows.Err(); err != nil {
+		return total, fmt.Errorf("bulk insert failed: %w", err)
+	}
+	if err := flush(); err != nil {
+		return total, fmt.Errorf("bulk insert failed: %w", err)
+	}
+	return total, nil
+}
+
+// rowSize estimates the bytes a row adds to the statement: a "?, " in the
+// text plus the encoded parameter.
+func rowSize(values []interface{}) int {
+	size := 4
+	for _, v := range values {
+		size += 12
+		switch val := v.(type) {
+		case nil:
+		case string:
+			size += len(val)
+		case []byte:
+			size += len(val)
+		case time.Time:
+			size += 12
+		default:
+			size += 8
+		}
+	}
+	return size
+}
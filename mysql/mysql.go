@@ -2,71 +2,125 @@ package mysql
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strconv"
-	"strings"
+	"sync"
 	"time"
 
+	"github.com/danielpnjt/go-library/contextwrap"
+	"github.com/danielpnjt/go-library/dberr"
+	"github.com/danielpnjt/go-library/metrics"
+	"github.com/danielpnjt/go-library/querylog"
+	"github.com/danielpnjt/go-library/replica"
+	"github.com/danielpnjt/go-library/tracing"
+	driver "github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 )
 
 type MysqlOop struct {
-	DB *sqlx.DB
+	// DB is the primary. Writes and transactions always use it.
+	DB   *sqlx.DB
+	host string
+
+	cfg      *driver.Config
+	opts     Options
+	mu       sync.Mutex
+	replicas *replica.Set[*sqlx.DB]
 }
 
+// Init connects over TCP with the given pool settings. connMaxLifetime and
+// connMaxIdleTime are in seconds; zero leaves them unset.
+//
+// Deprecated: use InitWithOptions, which also covers TLS, time zone,
+// timeouts and extra DSN parameters.
 func Init(user string, pass string, host string, dbname string, maxIdleConns, maxOpenConns, connMaxLifetime, connMaxIdleTime int) (*MysqlOop, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-	defer cancel()
+	return InitWithOptions(Options{
+		User:            user,
+		Password:        pass,
+		Host:            host,
+		DBName:          dbname,
+		MaxIdleConns:    maxIdleConns,
+		MaxOpenConns:    maxOpenConns,
+		ConnMaxLifetime: time.Duration(connMaxLifetime) * time.Second,
+		ConnMaxIdleTime: time.Duration(connMaxIdleTime) * time.Second,
+	})
+}
 
-	connectionString := fmt.Sprintf(`%s:%s@(%s)/%s?parseTime=true`, user, pass, host, dbname)
-	db, err := sqlx.ConnectContext(ctx, "mysql", connectionString)
+// query instruments a single statement.
+type query struct {
+	span      tracing.Span
+	name      string
+	target    string
+	ctx       context.Context
+	statement string
+	args      []interface{}
+	rows      int64
+	start     time.Time
+}
 
-	if err == nil {
-		if maxIdleConns > 0 {
-			db.SetMaxIdleConns(maxIdleConns)
-		}
+func (r *MysqlOop) startQuery(ctx context.Context, name string, queryStatement string, args ...interface{}) (context.Context, *query) {
+	ctx, span := tracing.StartSpan(ctx, name, "MySQL")
+	// Literals in the statement may carry personal data; spans only get
+	// its normalized form.
+	span.SetAttribute("db.statement", querylog.Normalize(metrics.ComponentMysql, queryStatement))
 
-		if maxOpenConns > 0 {
-			db.SetMaxOpenConns(maxOpenConns)
-		}
+	return ctx, &query{
+		span:      span,
+		name:      name,
+		target:    r.host,
+		ctx:       ctx,
+		statement: queryStatement,
+		args:      args,
+		start:     time.Now(),
 	}
+}
 
-	// valid value: 1:minute, 2:hour 25:second
-	if strconv.Itoa(connMaxLifetime) != "" {
-		// set based on this
-		split := strings.Split(strconv.Itoa(connMaxLifetime), ":")
-		if len(split) == 2 {
-			value := split[0]
-			timeType := split[1]
-
-			valueInt, err := strconv.Atoi(value)
-			if err == nil && valueInt > 0 {
-				// if value is int (otherwise not valid)
-				if timeType == "hour" {
-					db.SetConnMaxLifetime(time.Duration(valueInt) * time.Hour)
-				} else if timeType == "minute" {
-					db.SetConnMaxLifetime(time.Duration(valueInt) * time.Minute)
-				} else if timeType == "second" {
-					db.SetConnMaxLifetime(time.Duration(valueInt) * time.Second)
-				}
-			}
-		}
+func (q *query) end(err error) {
+	if err != nil {
+		q.span.RecordError(err)
 	}
+	metrics.Observe(metrics.ComponentMysql, q.name, q.target, q.start, err)
+	querylog.Observe(metrics.ComponentMysql, q.statement, q.args, q.start, q.rows, err)
 
-	mysqlClient := &MysqlOop{
-		DB: db,
+	tr := &contextwrap.TraceDB{
+		TraceMeta:    contextwrap.NewTraceMeta(q.start, err),
+		Driver:       metrics.ComponentMysql,
+		Host:         q.target,
+		Statement:    querylog.Normalize(metrics.ComponentMysql, q.statement),
+		RowsAffected: q.rows,
 	}
-
-	return mysqlClient, err
+	tr.Elapsed = tr.Took.String()
+	contextwrap.RecordTrace(q.ctx, tr)
+	q.span.End()
 }
 
 func (r *MysqlOop) Select(queryStatement string) ([]map[string]interface{}, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	return r.SelectContext(context.Background(), queryStatement)
+}
+
+// SelectContext reads from a healthy replica when there is one. A replica
+// that cannot be reached is marked down and the read is retried once on
+// the primary.
+func (r *MysqlOop) SelectContext(ctx context.Context, queryStatement string, args ...interface{}) ([]map[string]interface{}, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	rows, err := r.DB.QueryxContext(ctx, queryStatement)
+	db, host, node := r.reader(ctx)
+	results, err := r.selectFrom(ctx, db, host, queryStatement, args...)
+	if err != nil && r.retryOnPrimary(node, err) {
+		return r.selectFrom(ctx, r.DB, r.host, queryStatement, args...)
+	}
+	return results, err
+}
+
+func (r *MysqlOop) selectFrom(ctx context.Context, db *sqlx.DB, host string, queryStatement string, args ...interface{}) ([]map[string]interface{}, error) {
+	ctx, q := r.startQuery(ctx, "Select", queryStatement, args...)
+	q.target = host
+
+	rows, err := db.QueryxContext(ctx, queryStatement, args...)
 	if err != nil {
-		return nil, fmt.Errorf("query failed: %w", err)
+		q.end(err)
+		return nil, fmt.Errorf("query failed: %w", dberr.Classify(err))
 	}
 	defer rows.Close()
 
@@ -74,55 +128,138 @@ func (r *MysqlOop) Select(queryStatement string) ([]map[string]interface{}, erro
 	for rows.Next() {
 		row := make(map[string]interface{})
 		if err := rows.MapScan(row); err != nil {
-			return nil, fmt.Errorf("error scanning row: %w", err)
+			q.end(err)
+			return nil, fmt.Errorf("error scanning row: %w", dberr.Classify(err))
 		}
 		results = append(results, row)
 	}
+	q.rows = int64(len(results))
+	if err := rows.Err(); err != nil {
+		q.end(err)
+		return nil, fmt.Errorf("rows error: %w", dberr.Classify(err))
+	}
+	q.end(nil)
 
 	return results, nil
 }
 
 func (r *MysqlOop) Update(queryStatement string) (int, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	return r.UpdateContext(context.Background(), queryStatement)
+}
+
+func (r *MysqlOop) UpdateContext(ctx context.Context, queryStatement string, args ...interface{}) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	result, err := r.DB.ExecContext(ctx, queryStatement)
+	ctx, q := r.startQuery(ctx, "Update", queryStatement, args...)
+
+	result, err := r.DB.ExecContext(ctx, queryStatement, args...)
 	if err != nil {
-		return 0, fmt.Errorf("update query failed: %w", err)
+		q.end(err)
+		return 0, fmt.Errorf("update query failed: %w", dberr.Classify(err))
 	}
 	rowsAffected, _ := result.RowsAffected()
+	q.rows = rowsAffected
+	q.end(nil)
+
 	return int(rowsAffected), nil
 }
 
 func (r *MysqlOop) Delete(queryStatement string) (int, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	return r.DeleteContext(context.Background(), queryStatement)
+}
+
+func (r *MysqlOop) DeleteContext(ctx context.Context, queryStatement string, args ...interface{}) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	result, err := r.DB.ExecContext(ctx, queryStatement)
+	ctx, q := r.startQuery(ctx, "Delete", queryStatement, args...)
+
+	result, err := r.DB.ExecContext(ctx, queryStatement, args...)
 	if err != nil {
-		return 0, fmt.Errorf("delete query failed: %w", err)
+		q.end(err)
+		return 0, fmt.Errorf("delete query failed: %w", dberr.Classify(err))
 	}
 	rowsAffected, err := result.RowsAffected()
+	q.rows = rowsAffected
+	q.end(err)
 	if err != nil {
-		return 0, fmt.Errorf("failed to del row affected: %w", err)
+		return 0, fmt.Errorf("failed to del row affected: %w", dberr.Classify(err))
 	}
 
 	return int(rowsAffected), nil
 }
 
 func (r *MysqlOop) Insert(queryStatement string) (int, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	return r.InsertContext(context.Background(), queryStatement)
+}
+
+func (r *MysqlOop) InsertContext(ctx context.Context, queryStatement string, args ...interface{}) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	result, err := r.DB.ExecContext(ctx, queryStatement)
+	ctx, q := r.startQuery(ctx, "Insert", queryStatement, args...)
+
+	result, err := r.DB.ExecContext(ctx, queryStatement, args...)
 	if err != nil {
-		return 0, fmt.Errorf("insert query failed: %w", err)
+		q.end(err)
+		return 0, fmt.Errorf("insert query failed: %w", dberr.Classify(err))
 	}
 
 	rowsAffected, err := result.RowsAffected()
+	q.rows = rowsAffected
+	q.end(err)
 	if err != nil {
-		return 0, fmt.Errorf("failed to insert row affected: %w", err)
+		return 0, fmt.Errorf("failed to insert row affected: %w", dberr.Classify(err))
 	}
 
 	return int(rowsAffected), nil
 }
+
+// Transaction runs fn in a transaction on the primary. It is committed
+// when fn returns nil and rolled back when fn fails or panics.
+func (r *MysqlOop) Transaction(ctx context.Context, fn func(tx *sqlx.Tx) error) (err error) {
+	ctx, q := r.startQuery(ctx, "Transaction", "BEGIN")
+	defer func() {
+		q.end(err)
+	}()
+
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction failed: %w", dberr.Classify(err))
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit failed: %w", dberr.Classify(err))
+	}
+	return nil
+}
+
+// HealthCheck pings the database.
+func (r *MysqlOop) HealthCheck(ctx context.Context) error {
+	return r.DB.PingContext(ctx)
+}
+
+// Close closes the primary and replica pools. Queries already running are
+// allowed to finish.
+func (r *MysqlOop) Close(ctx context.Context) error {
+	var errs []error
+	if set := r.replicaSet(); set != nil {
+		set.Stop()
+		for _, n := range set.Nodes() {
+			errs = append(errs, n.DB.Close())
+		}
+	}
+	errs = append(errs, r.DB.Close())
+	return errors.Join(errs...)
+}
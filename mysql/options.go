@@ -0,0 +1,160 @@
+package mysql
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/danielpnjt/go-library/dberr"
+	"github.com/danielpnjt/go-library/replica"
+	driver "github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+)
+
+// Options configures InitWithOptions. When DSN is set it is parsed as a
+// go-sql-driver DSN and the connection fields are ignored; the pool fields
+// apply either way.
+type Options struct {
+	DSN string
+
+	User     string
+	Password string
+	// Host is host:port, or a socket path when Net is "unix".
+	Host   string
+	Net    string
+	DBName string
+
+	// Charset defaults to utf8mb4.
+	Charset   string
+	Collation string
+	// Loc is the time zone DATETIME and TIMESTAMP values are read in.
+	// Defaults to UTC.
+	Loc       *time.Location
+	TLSConfig *tls.Config
+
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+
+	// Params are extra DSN parameters, sent as session variables by the
+	// driver, e.g. {"sql_mode": "'STRICT_ALL_TABLES'"}.
+	Params map[string]string
+
+	MaxIdleConns    int
+	MaxOpenConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// Replicas lists host:port addresses of read replicas and how reads
+	// are spread over them. See SetReplicas.
+	Replicas replica.Options
+}
+
+func (opts Options) config() (*driver.Config, error) {
+	if opts.DSN != "" {
+		cfg, err := driver.ParseDSN(opts.DSN)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing dsn: %w", err)
+		}
+		return cfg, nil
+	}
+
+	cfg := driver.NewConfig()
+	cfg.User = opts.User
+	cfg.Passwd = opts.Password
+	cfg.Net = "tcp"
+	if opts.Net != "" {
+		cfg.Net = opts.Net
+	}
+	cfg.Addr = opts.Host
+	cfg.DBName = opts.DBName
+	cfg.ParseTime = true
+
+	cfg.Loc = time.UTC
+	if opts.Loc != nil {
+		cfg.Loc = opts.Loc
+	}
+	if opts.Collation != "" {
+		cfg.Collation = opts.Collation
+	}
+	cfg.TLS = opts.TLSConfig
+
+	cfg.Timeout = opts.DialTimeout
+	cfg.ReadTimeout = opts.ReadTimeout
+	cfg.WriteTimeout = opts.WriteTimeout
+
+	cfg.Params = make(map[string]string, len(opts.Params)+1)
+	charset := opts.Charset
+	if charset == "" {
+		charset = "utf8mb4"
+	}
+	cfg.Params["charset"] = charset
+	for k, v := range opts.Params {
+		cfg.Params[k] = v
+	}
+
+	return cfg, nil
+}
+
+// openPool opens a pool for cfg without connecting.
+func openPool(cfg *driver.Config, opts Options) (*sqlx.DB, error) {
+	connector, err := driver.NewConnector(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing config: %w", err)
+	}
+	db := sqlx.NewDb(sql.OpenDB(connector), "mysql")
+
+	if opts.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(opts.MaxIdleConns)
+	}
+	if opts.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(opts.MaxOpenConns)
+	}
+	if opts.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	}
+	if opts.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
+	}
+	return db, nil
+}
+
+// InitWithOptions opens a pool with the go-sql-driver MySQL driver and
+// checks it with a ping. Replicas are added without failing the call when
+// they are unreachable; reads use the primary until they come up.
+func InitWithOptions(opts Options) (*MysqlOop, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	cfg, err := opts.config()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := openPool(cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect: %w", dberr.Classify(err))
+	}
+
+	mysqlClient := &MysqlOop{
+		DB:   db,
+		host: cfg.Addr,
+		cfg:  cfg,
+		opts: opts,
+	}
+
+	if len(opts.Replicas.Hosts) > 0 {
+		if err := mysqlClient.SetReplicas(ctx, opts.Replicas); err != nil {
+			mysqlClient.Close(ctx)
+			return nil, err
+		}
+	}
+
+	return mysqlClient, nil
+}
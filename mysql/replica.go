@@ -0,0 +1,84 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/danielpnjt/go-library/contextwrap"
+	"github.com/danielpnjt/go-library/dberr"
+	"github.com/danielpnjt/go-library/replica"
+	"github.com/jmoiron/sqlx"
+)
+
+// SetReplicas opens the read replicas in opts (host:port addresses) with
+// the primary's credentials and settings. It can be called once; Options
+// Replicas calls it from InitWithOptions. An unreachable replica is still
+// added and starts taking reads once its health check passes.
+func (r *MysqlOop) SetReplicas(ctx context.Context, opts replica.Options) error {
+	if r.cfg == nil {
+		return errors.New("mysql: replicas need a client from Init or InitWithOptions")
+	}
+	if r.replicaSet() != nil {
+		return errors.New("mysql: replicas are already set")
+	}
+
+	dbs := make([]*sqlx.DB, 0, len(opts.Hosts))
+	for _, host := range opts.Hosts {
+		cfg := r.cfg.Clone()
+		cfg.Addr = host
+		db, err := openPool(cfg, r.opts)
+		if err != nil {
+			for _, db := range dbs {
+				db.Close()
+			}
+			return fmt.Errorf("replica %s: %w", host, err)
+		}
+		dbs = append(dbs, db)
+	}
+
+	set := replica.New(opts.Strategy, opts.CheckInterval, func(ctx context.Context, db *sqlx.DB) error {
+		return db.PingContext(ctx)
+	})
+	for i, db := range dbs {
+		set.Add(ctx, db, opts.Hosts[i])
+	}
+
+	r.mu.Lock()
+	r.replicas = set
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *MysqlOop) replicaSet() *replica.Set[*sqlx.DB] {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.replicas
+}
+
+// reader picks the pool for a read: a healthy replica, or the primary when
+// ctx forces it or no replica is up. node is nil for the primary.
+func (r *MysqlOop) reader(ctx context.Context) (db *sqlx.DB, host string, node *replica.Node[*sqlx.DB]) {
+	if contextwrap.GetForcePrimaryFromContext(ctx) {
+		return r.DB, r.host, nil
+	}
+	if set := r.replicaSet(); set != nil {
+		if n, ok := set.Pick(); ok {
+			return n.DB, n.Host, n
+		}
+	}
+	return r.DB, r.host, nil
+}
+
+// retryOnPrimary reports whether a read on node failed because the replica
+// could not be reached. The node is then marked down so the following
+// reads skip it, and the caller retries the read once on the primary.
+func (r *MysqlOop) retryOnPrimary(node *replica.Node[*sqlx.DB], err error) bool {
+	if node == nil || !errors.Is(err, dberr.ErrConnection) {
+		return false
+	}
+	if set := r.replicaSet(); set != nil {
+		set.MarkDown(node)
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/danielpnjt/go-library/replica"
+	"github.com/jmoiron/sqlx"
+)
+
+func newMockDB(t *testing.T) (*sqlx.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return sqlx.NewDb(db, "mysql"), mock
+}
+
+// newReplicaClient returns a client whose only replica is healthy until a
+// read on it fails.
+func newReplicaClient(t *testing.T) (*MysqlOop, sqlmock.Sqlmock, sqlmock.Sqlmock, *replica.Node[*sqlx.DB]) {
+	t.Helper()
+	primary, primaryMock := newMockDB(t)
+	replicaDB, replicaMock := newMockDB(t)
+
+	set := replica.New(replica.RoundRobin, time.Hour, func(ctx context.Context, db *sqlx.DB) error {
+		return nil
+	})
+	t.Cleanup(set.Stop)
+	node := set.Add(context.Background(), replicaDB, "replica")
+
+	r := &MysqlOop{DB: primary, host: "primary", replicas: set}
+	return r, primaryMock, replicaMock, node
+}
+
+func TestSelectRetriesOnPrimaryWhenReplicaIsUnreachable(t *testing.T) {
+	r, primaryMock, replicaMock, node := newReplicaClient(t)
+
+	refused := &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+	replicaMock.ExpectQuery("SELECT id FROM users").WillReturnError(refused)
+	primaryMock.ExpectQuery("SELECT id FROM users").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	rows, err := r.SelectContext(context.Background(), "SELECT id FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("got %d rows, want 1", len(rows))
+	}
+	if node.Healthy() {
+		t.Fatal("replica still healthy after a connection failure")
+	}
+	for _, mock := range []sqlmock.Sqlmock{primaryMock, replicaMock} {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The next read skips the replica.
+	primaryMock.ExpectQuery("SELECT id FROM users").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	if _, err := r.SelectContext(context.Background(), "SELECT id FROM users"); err != nil {
+		t.Fatal(err)
+	}
+	if err := primaryMock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSelectKeepsReplicaOnQueryError(t *testing.T) {
+	r, primaryMock, replicaMock, node := newReplicaClient(t)
+
+	replicaMock.ExpectQuery("SELECT nope").WillReturnError(errors.New("syntax error"))
+
+	if _, err := r.SelectContext(context.Background(), "SELECT nope"); err == nil {
+		t.Fatal("expected an error")
+	}
+	if !node.Healthy() {
+		t.Fatal("replica marked down for a query error")
+	}
+	if err := primaryMock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQueryRetriesOnPrimaryBeforeAnyRow(t *testing.T) {
+	r, primaryMock, replicaMock, node := newReplicaClient(t)
+
+	refused := &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+	replicaMock.ExpectQuery("SELECT id FROM users").WillReturnError(refused)
+	primaryMock.ExpectQuery("SELECT id FROM users").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+
+	var seen int
+	err := r.Query(context.Background(), "SELECT id FROM users", nil, func(row map[string]interface{}) error {
+		seen++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seen != 2 || node.Healthy() {
+		t.Fatalf("seen = %d, replica healthy = %v", seen, node.Healthy())
+	}
+}
@@ -0,0 +1,63 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/danielpnjt/go-library/tracing"
+	"github.com/jmoiron/sqlx"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestContextCallsCreateChildSpans(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	tracing.Init(tracing.NewOTel(tp))
+	defer tracing.Init(tracing.Noop())
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	r := &MysqlOop{DB: sqlx.NewDb(db, "mysql"), host: "mock"}
+
+	mock.ExpectQuery("SELECT id FROM users").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec("UPDATE users").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ctx, parent := tracing.StartSpan(context.Background(), "parent", "test")
+	if _, err := r.SelectContext(ctx, "SELECT id FROM users WHERE name = 'bob'"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.UpdateContext(ctx, "UPDATE users SET name = ? WHERE id = 7", "alice"); err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	parentID := trace.SpanContextFromContext(ctx).SpanID()
+	want := map[string]string{
+		"Select": "SELECT id FROM users WHERE name = ?",
+		"Update": "UPDATE users SET name = ? WHERE id = ?",
+	}
+	for _, s := range exp.GetSpans() {
+		statement, ok := want[s.Name]
+		if !ok {
+			continue
+		}
+		delete(want, s.Name)
+		if s.Parent.SpanID() != parentID {
+			t.Errorf("%s span is not a child of parent", s.Name)
+		}
+		for _, attr := range s.Attributes {
+			if attr.Key == "db.statement" && attr.Value.AsString() != statement {
+				t.Errorf("%s db.statement = %q, want %q", s.Name, attr.Value.AsString(), statement)
+			}
+		}
+	}
+	if len(want) > 0 {
+		t.Fatalf("missing spans: %v", want)
+	}
+}
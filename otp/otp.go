@@ -0,0 +1,261 @@
+package otp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/danielpnjt/go-library/redis"
+)
+
+const (
+	defaultLength         = 6
+	defaultTTL            = 5 * time.Minute
+	defaultMaxAttempts    = 3
+	defaultLockout        = 15 * time.Minute
+	defaultResendInterval = time.Minute
+	defaultPrefix         = "otp"
+)
+
+var (
+	ErrExpired = errors.New("otp: code expired or not requested")
+	ErrInvalid = errors.New("otp: invalid code")
+	ErrLocked  = errors.New("otp: locked after too many failed attempts")
+	ErrTooSoon = errors.New("otp: resend requested too soon")
+)
+
+// Error carries the retry details of a failed Generate or Verify. It wraps
+// one of ErrExpired, ErrInvalid, ErrLocked or ErrTooSoon for errors.Is.
+type Error struct {
+	Err          error
+	RetryAfter   time.Duration
+	AttemptsLeft int
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.RetryAfter > 0:
+		return fmt.Sprintf("%s, retry after %s", e.Err, e.RetryAfter.Round(time.Second))
+	case errors.Is(e.Err, ErrInvalid):
+		return fmt.Sprintf("%s, %d attempts left", e.Err, e.AttemptsLeft)
+	default:
+		return e.Err.Error()
+	}
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+type Config struct {
+	// Length is the number of digits, 6 by default.
+	Length int
+	// TTL is how long a generated code stays valid, 5 minutes by default.
+	TTL time.Duration
+	// MaxAttempts failed verifications lock the subject for LockoutDuration.
+	MaxAttempts     int
+	LockoutDuration time.Duration
+	// ResendInterval is the minimum time between two codes for a subject,
+	// one minute by default. A negative value disables throttling.
+	ResendInterval time.Duration
+	// Secret keys the HMAC used to store codes. Without it codes are stored
+	// as plain SHA-256, which is brute-forceable for short codes.
+	Secret []byte
+	Prefix string
+}
+
+type OTP struct {
+	redis *redis.RedisOop
+	cfg   Config
+}
+
+// Codes, failure counters, lockouts and resend windows live under one
+// {hash tag} per subject so the scripts stay valid under Redis Cluster.
+// Failures are counted per subject rather than per code, so requesting a
+// new code does not reset them.
+var generateScript = redis.NewScript(`
+local locked = redis.call("pttl", KEYS[2])
+if locked > 0 then
+	return {-3, locked}
+end
+local resend = redis.call("pttl", KEYS[3])
+if resend > 0 then
+	return {-4, resend}
+end
+redis.call("del", KEYS[1])
+redis.call("hset", KEYS[1], "h", ARGV[1])
+redis.call("pexpire", KEYS[1], ARGV[2])
+if tonumber(ARGV[3]) > 0 then
+	redis.call("set", KEYS[3], "1", "PX", ARGV[3])
+end
+return {1, 0}
+`)
+
+var verifyScript = redis.NewScript(`
+local locked = redis.call("pttl", KEYS[2])
+if locked > 0 then
+	return {-3, locked}
+end
+local h = redis.call("hget", KEYS[1], "h")
+if not h then
+	return {-1, 0}
+end
+if h == ARGV[1] then
+	redis.call("del", KEYS[1], KEYS[4])
+	return {1, 0}
+end
+local attempts = redis.call("incr", KEYS[4])
+redis.call("pexpire", KEYS[4], ARGV[3])
+if attempts >= tonumber(ARGV[2]) then
+	redis.call("del", KEYS[1], KEYS[4])
+	redis.call("set", KEYS[2], "1", "PX", ARGV[3])
+	return {-3, tonumber(ARGV[3])}
+end
+return {-2, tonumber(ARGV[2]) - attempts}
+`)
+
+func New(r *redis.RedisOop, cfg Config) *OTP {
+	if cfg.Length <= 0 {
+		cfg.Length = defaultLength
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = defaultTTL
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultMaxAttempts
+	}
+	if cfg.LockoutDuration <= 0 {
+		cfg.LockoutDuration = defaultLockout
+	}
+	if cfg.ResendInterval < 0 {
+		cfg.ResendInterval = 0
+	} else if cfg.ResendInterval == 0 {
+		cfg.ResendInterval = defaultResendInterval
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = defaultPrefix
+	}
+
+	return &OTP{
+		redis: r,
+		cfg:   cfg,
+	}
+}
+
+// Generate creates and stores a new code for subject, replacing any code
+// still pending. It fails with ErrLocked or ErrTooSoon.
+func (o *OTP) Generate(ctx context.Context, subject string) (string, error) {
+	code, err := o.newCode()
+	if err != nil {
+		return "", err
+	}
+
+	res, err := o.redis.RunScript(ctx, generateScript, o.keys(subject),
+		o.hash(subject, code),
+		o.cfg.TTL.Milliseconds(),
+		o.cfg.ResendInterval.Milliseconds(),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	status, value, err := parseResult(res)
+	if err != nil {
+		return "", err
+	}
+
+	switch status {
+	case 1:
+		return code, nil
+	case -3:
+		return "", &Error{Err: ErrLocked, RetryAfter: time.Duration(value) * time.Millisecond}
+	case -4:
+		return "", &Error{Err: ErrTooSoon, RetryAfter: time.Duration(value) * time.Millisecond}
+	default:
+		return "", fmt.Errorf("otp: unexpected generate status %d", status)
+	}
+}
+
+// Verify checks code for subject. A correct code is consumed and clears the
+// failed attempts; a wrong one counts as a failed attempt and locks the
+// subject after MaxAttempts. Failures older than LockoutDuration are
+// forgotten.
+func (o *OTP) Verify(ctx context.Context, subject string, code string) error {
+	res, err := o.redis.RunScript(ctx, verifyScript, o.keys(subject),
+		o.hash(subject, code),
+		o.cfg.MaxAttempts,
+		o.cfg.LockoutDuration.Milliseconds(),
+	)
+	if err != nil {
+		return err
+	}
+
+	status, value, err := parseResult(res)
+	if err != nil {
+		return err
+	}
+
+	switch status {
+	case 1:
+		return nil
+	case -1:
+		return &Error{Err: ErrExpired}
+	case -2:
+		return &Error{Err: ErrInvalid, AttemptsLeft: int(value)}
+	case -3:
+		return &Error{Err: ErrLocked, RetryAfter: time.Duration(value) * time.Millisecond}
+	default:
+		return fmt.Errorf("otp: unexpected verify status %d", status)
+	}
+}
+
+// Invalidate drops the pending code for subject. Failed attempts, lockouts
+// and the resend window are kept.
+func (o *OTP) Invalidate(ctx context.Context, subject string) error {
+	return o.redis.DeleteContext(ctx, o.keys(subject)[0])
+}
+
+func (o *OTP) keys(subject string) []string {
+	tag := fmt.Sprintf("%s:{%s}", o.cfg.Prefix, subject)
+	return []string{tag + ":code", tag + ":lock", tag + ":resend", tag + ":fails"}
+}
+
+func (o *OTP) hash(subject string, code string) string {
+	msg := []byte(subject + ":" + code)
+	if len(o.cfg.Secret) == 0 {
+		sum := sha256.Sum256(msg)
+		return hex.EncodeToString(sum[:])
+	}
+
+	mac := hmac.New(sha256.New, o.cfg.Secret)
+	mac.Write(msg)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (o *OTP) newCode() (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(o.cfg.Length)), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", o.cfg.Length, n), nil
+}
+
+func parseResult(res interface{}) (int64, int64, error) {
+	values, ok := res.([]interface{})
+	if !ok || len(values) != 2 {
+		return 0, 0, fmt.Errorf("otp: unexpected script result %v", res)
+	}
+	status, ok1 := values[0].(int64)
+	value, ok2 := values[1].(int64)
+	if !ok1 || !ok2 {
+		return 0, 0, fmt.Errorf("otp: unexpected script result %v", res)
+	}
+	return status, value, nil
+}
@@ -0,0 +1,145 @@
+package otp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/danielpnjt/go-library/redis"
+)
+
+func newTestOTP(t *testing.T, cfg Config) (*OTP, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	r, err := redis.Init(mr.Addr(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		r.Close(context.Background())
+	})
+	return New(r, cfg), mr
+}
+
+// wrongCode returns a code of the same length that differs from code.
+func wrongCode(code string) string {
+	b := []byte(code)
+	b[0] = '0' + (b[0]-'0'+1)%10
+	return string(b)
+}
+
+func TestVerify(t *testing.T) {
+	o, _ := newTestOTP(t, Config{})
+	ctx := context.Background()
+
+	code, err := o.Generate(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = o.Verify(ctx, "alice", wrongCode(code))
+	var oerr *Error
+	if !errors.As(err, &oerr) || !errors.Is(err, ErrInvalid) || oerr.AttemptsLeft != 2 {
+		t.Fatalf("wrong code: err = %v", err)
+	}
+
+	if err := o.Verify(ctx, "alice", code); err != nil {
+		t.Fatalf("right code: %v", err)
+	}
+	// A code is consumed by a successful verification.
+	if err := o.Verify(ctx, "alice", code); !errors.Is(err, ErrExpired) {
+		t.Fatalf("reused code: err = %v, want ErrExpired", err)
+	}
+}
+
+func TestVerifyExpired(t *testing.T) {
+	o, mr := newTestOTP(t, Config{TTL: time.Minute})
+	ctx := context.Background()
+
+	code, err := o.Generate(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mr.FastForward(time.Minute)
+
+	if err := o.Verify(ctx, "alice", code); !errors.Is(err, ErrExpired) {
+		t.Fatalf("err = %v, want ErrExpired", err)
+	}
+}
+
+func TestLockoutSurvivesRegenerate(t *testing.T) {
+	o, mr := newTestOTP(t, Config{MaxAttempts: 3, ResendInterval: time.Second, LockoutDuration: time.Hour})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		code, err := o.Generate(ctx, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = o.Verify(ctx, "alice", wrongCode(code))
+		if i < 2 && !errors.Is(err, ErrInvalid) {
+			t.Fatalf("attempt %d: err = %v, want ErrInvalid", i+1, err)
+		}
+		if i == 2 && !errors.Is(err, ErrLocked) {
+			t.Fatalf("attempt %d: err = %v, want ErrLocked", i+1, err)
+		}
+		mr.FastForward(time.Second)
+	}
+
+	var oerr *Error
+	_, err := o.Generate(ctx, "alice")
+	if !errors.As(err, &oerr) || !errors.Is(err, ErrLocked) || oerr.RetryAfter <= 0 {
+		t.Fatalf("generate while locked: err = %v", err)
+	}
+
+	mr.FastForward(time.Hour)
+	code, err := o.Generate(ctx, "alice")
+	if err != nil {
+		t.Fatalf("generate after lockout: %v", err)
+	}
+	if err := o.Verify(ctx, "alice", code); err != nil {
+		t.Fatalf("verify after lockout: %v", err)
+	}
+}
+
+func TestSuccessClearsFailures(t *testing.T) {
+	o, mr := newTestOTP(t, Config{MaxAttempts: 2, ResendInterval: -1})
+	ctx := context.Background()
+
+	code, err := o.Generate(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Verify(ctx, "alice", wrongCode(code)); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("err = %v, want ErrInvalid", err)
+	}
+	if err := o.Verify(ctx, "alice", code); err != nil {
+		t.Fatal(err)
+	}
+	if mr.Exists("otp:{alice}:fails") {
+		t.Fatal("failure counter kept after a successful verification")
+	}
+}
+
+func TestResendThrottle(t *testing.T) {
+	o, mr := newTestOTP(t, Config{ResendInterval: time.Minute})
+	ctx := context.Background()
+
+	if _, err := o.Generate(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	var oerr *Error
+	_, err := o.Generate(ctx, "alice")
+	if !errors.As(err, &oerr) || !errors.Is(err, ErrTooSoon) || oerr.RetryAfter <= 0 || oerr.RetryAfter > time.Minute {
+		t.Fatalf("second generate: err = %v", err)
+	}
+
+	mr.FastForward(time.Minute)
+	if _, err := o.Generate(ctx, "alice"); err != nil {
+		t.Fatalf("generate after the interval: %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/danielpnjt/go-library/dberr"
+	"github.com/jackc/pgx/v5"
+)
+
+const defaultProgressEvery = 10000
+
+// RowSource yields the rows of a bulk insert one at a time. Any
+// pgx.CopyFromSource, such as pgx.CopyFromRows, satisfies it.
+type RowSource interface {
+	Next() bool
+	Values() ([]interface{}, error)
+	Err() error
+}
+
+// SliceRows is a RowSource over rows held in memory.
+func SliceRows(rows [][]interface{}) RowSource {
+	return pgx.CopyFromRows(rows)
+}
+
+// BulkProgress is reported after every chunk.
+type BulkProgress struct {
+	Chunk int
+	Rows  int
+	Total int64
+}
+
+type BulkOptions struct {
+	// ProgressEvery is how many rows make up one progress chunk. Defaults
+	// to 10000. Unlike the MySQL MaxRows it does not split the write: the
+	// copy itself is a single stream.
+	ProgressEvery int
+	// Progress is called each time a chunk of rows has been streamed to
+	// the server, and once more for the remainder when the copy completes.
+	Progress func(p BulkProgress)
+}
+
+// progressSource counts the rows pgx pulls from src and reports each chunk.
+type progressSource struct {
+	RowSource
+	opts  BulkOptions
+	chunk int
+	rows  int
+	total int64
+}
+
+func (s *progressSource) Next() bool {
+	if s.rows == s.opts.ProgressEvery {
+		s.report()
+	}
+	if !s.RowSource.Next() {
+		return false
+	}
+	s.rows++
+	s.total++
+	return true
+}
+
+func (s *progressSource) report() {
+	if s.rows == 0 {
+		return
+	}
+	s.chunk++
+	if s.opts.Progress != nil {
+		s.opts.Progress(BulkProgress{Chunk: s.chunk, Rows: s.rows, Total: s.total})
+	}
+	s.rows = 0
+}
+
+// BulkInsert streams rows into table with COPY on the primary. table may be
+// schema qualified. The copy is atomic: on error no rows are inserted.
+func (r *PostgresOop) BulkInsert(ctx context.Context, table string, columns []string, rows RowSource, opts BulkOptions) (total int64, err error) {
+	ctx, q := r.startQuery(ctx, "BulkInsert", "COPY "+table)
+	defer func() {
+		q.rows = total
+		q.end(err)
+	}()
+
+	if len(columns) == 0 {
+		return 0, errors.New("bulk insert failed: no columns")
+	}
+	if opts.ProgressEvery <= 0 {
+		opts.ProgressEvery = defaultProgressEvery
+	}
+
+	src := &progressSource{RowSource: rows, opts: opts}
+	total, err = r.DB.CopyFrom(ctx, pgx.Identifier(strings.Split(table, ".")), columns, src)
+	if err != nil {
+		return 0, fmt.Errorf("bulk insert failed: %w", dberr.Classify(err))
+	}
+	src.report()
+	return total, nil
+}
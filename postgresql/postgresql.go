@@ -5,13 +5,27 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/danielpnjt/go-library/contextwrap"
+	"github.com/danielpnjt/go-library/dberr"
+	"github.com/danielpnjt/go-library/metrics"
+	"github.com/danielpnjt/go-library/querylog"
+	"github.com/danielpnjt/go-library/replica"
+	"github.com/danielpnjt/go-library/tracing"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type PostgresOop struct {
-	DB *pgxpool.Pool
+	// DB is the primary. Writes and transactions always use it.
+	DB   *pgxpool.Pool
+	host string
+
+	config   *pgxpool.Config
+	mu       sync.Mutex
+	replicas *replica.Set[*pgxpool.Pool]
 }
 
 func Init(user, pass, host, dbname string, maxConns int, connMaxLifetime, connMaxIdleTime string) (*PostgresOop, error) {
@@ -43,11 +57,13 @@ func Init(user, pass, host, dbname string, maxConns int, connMaxLifetime, connMa
 
 	dbpool, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect: %w", err)
+		return nil, fmt.Errorf("failed to connect: %w", dberr.Classify(err))
 	}
 
 	return &PostgresOop{
-		DB: dbpool,
+		DB:     dbpool,
+		host:   config.ConnConfig.Host,
+		config: config,
 	}, nil
 }
 
@@ -72,13 +88,81 @@ func ParseDuration(input string) time.Duration {
 	}
 }
 
+// query instruments a single statement.
+type query struct {
+	span      tracing.Span
+	name      string
+	target    string
+	ctx       context.Context
+	statement string
+	args      []interface{}
+	rows      int64
+	start     time.Time
+}
+
+func (r *PostgresOop) startQuery(ctx context.Context, name string, queryStatement string, args ...interface{}) (context.Context, *query) {
+	ctx, span := tracing.StartSpan(ctx, name, "PostgreSQL")
+	// Literals in the statement may carry personal data; spans only get
+	// its normalized form.
+	span.SetAttribute("db.statement", querylog.Normalize(metrics.ComponentPostgres, queryStatement))
+
+	return ctx, &query{
+		span:      span,
+		name:      name,
+		target:    r.host,
+		ctx:       ctx,
+		statement: queryStatement,
+		args:      args,
+		start:     time.Now(),
+	}
+}
+
+func (q *query) end(err error) {
+	if err != nil {
+		q.span.RecordError(err)
+	}
+	metrics.Observe(metrics.ComponentPostgres, q.name, q.target, q.start, err)
+	querylog.Observe(metrics.ComponentPostgres, q.statement, q.args, q.start, q.rows, err)
+
+	tr := &contextwrap.TraceDB{
+		TraceMeta:    contextwrap.NewTraceMeta(q.start, err),
+		Driver:       metrics.ComponentPostgres,
+		Host:         q.target,
+		Statement:    querylog.Normalize(metrics.ComponentPostgres, q.statement),
+		RowsAffected: q.rows,
+	}
+	tr.Elapsed = tr.Took.String()
+	contextwrap.RecordTrace(q.ctx, tr)
+	q.span.End()
+}
+
 func (r *PostgresOop) Select(queryStatement string) ([]map[string]interface{}, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	return r.SelectContext(context.Background(), queryStatement)
+}
+
+// SelectContext reads from a healthy replica when there is one. A replica
+// that cannot be reached is marked down and the read is retried once on
+// the primary.
+func (r *PostgresOop) SelectContext(ctx context.Context, queryStatement string, args ...interface{}) ([]map[string]interface{}, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	rows, err := r.DB.Query(ctx, queryStatement)
+	db, host, node := r.reader(ctx)
+	results, err := r.selectFrom(ctx, db, host, queryStatement, args...)
+	if err != nil && r.retryOnPrimary(node, err) {
+		return r.selectFrom(ctx, r.DB, r.host, queryStatement, args...)
+	}
+	return results, err
+}
+
+func (r *PostgresOop) selectFrom(ctx context.Context, db *pgxpool.Pool, host string, queryStatement string, args ...interface{}) ([]map[string]interface{}, error) {
+	ctx, q := r.startQuery(ctx, "Select", queryStatement, args...)
+	q.target = host
+
+	rows, err := db.Query(ctx, queryStatement, args...)
 	if err != nil {
-		return nil, fmt.Errorf("query failed: %w", err)
+		q.end(err)
+		return nil, fmt.Errorf("query failed: %w", dberr.Classify(err))
 	}
 	defer rows.Close()
 
@@ -92,7 +176,8 @@ func (r *PostgresOop) Select(queryStatement string) ([]map[string]interface{}, e
 	for rows.Next() {
 		values, err := rows.Values()
 		if err != nil {
-			return nil, fmt.Errorf("error reading row: %w", err)
+			q.end(err)
+			return nil, fmt.Errorf("error reading row: %w", dberr.Classify(err))
 		}
 
 		rowMap := make(map[string]interface{})
@@ -103,47 +188,111 @@ func (r *PostgresOop) Select(queryStatement string) ([]map[string]interface{}, e
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows error: %w", err)
+		q.end(err)
+		return nil, fmt.Errorf("rows error: %w", dberr.Classify(err))
 	}
+	q.rows = int64(len(results))
+	q.end(nil)
 
 	return results, nil
 }
 
 func (r *PostgresOop) Update(queryStatement string) (int, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	result, err := r.DB.Exec(ctx, queryStatement)
-	if err != nil {
-		return 0, fmt.Errorf("update query failed: %w", err)
-	}
+	return r.UpdateContext(context.Background(), queryStatement)
+}
 
-	rowsAffected := int(result.RowsAffected())
-	return rowsAffected, nil
+func (r *PostgresOop) UpdateContext(ctx context.Context, queryStatement string, args ...interface{}) (int, error) {
+	return r.exec(ctx, "Update", queryStatement, args...)
 }
 
 func (r *PostgresOop) Delete(queryStatement string) (int, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	return r.DeleteContext(context.Background(), queryStatement)
+}
+
+func (r *PostgresOop) DeleteContext(ctx context.Context, queryStatement string, args ...interface{}) (int, error) {
+	return r.exec(ctx, "Delete", queryStatement, args...)
+}
+
+func (r *PostgresOop) Insert(queryStatement string) (int, error) {
+	return r.InsertContext(context.Background(), queryStatement)
+}
+
+func (r *PostgresOop) InsertContext(ctx context.Context, queryStatement string, args ...interface{}) (int, error) {
+	return r.exec(ctx, "Insert", queryStatement, args...)
+}
+
+func (r *PostgresOop) exec(ctx context.Context, name string, queryStatement string, args ...interface{}) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	result, err := r.DB.Exec(ctx, queryStatement)
+	ctx, q := r.startQuery(ctx, name, queryStatement, args...)
+
+	result, err := r.DB.Exec(ctx, queryStatement, args...)
 	if err != nil {
-		return 0, fmt.Errorf("delete query failed: %w", err)
+		q.end(err)
+		return 0, fmt.Errorf("%s query failed: %w", strings.ToLower(name), dberr.Classify(err))
 	}
 
+	q.rows = result.RowsAffected()
+	q.end(nil)
+
 	rowsAffected := int(result.RowsAffected())
 	return rowsAffected, nil
 }
 
-func (r *PostgresOop) Insert(queryStatement string) (int, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+// Transaction runs fn in a transaction on the primary. It is committed
+// when fn returns nil and rolled back when fn fails or panics.
+func (r *PostgresOop) Transaction(ctx context.Context, fn func(tx pgx.Tx) error) (err error) {
+	ctx, q := r.startQuery(ctx, "Transaction", "BEGIN")
+	defer func() {
+		q.end(err)
+	}()
 
-	result, err := r.DB.Exec(ctx, queryStatement)
+	tx, err := r.DB.Begin(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("insert query failed: %w", err)
+		return fmt.Errorf("begin transaction failed: %w", dberr.Classify(err))
 	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback(ctx)
+			panic(p)
+		}
+	}()
 
-	rowsAffected := int(result.RowsAffected())
-	return rowsAffected, nil
+	if err = fn(tx); err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit failed: %w", dberr.Classify(err))
+	}
+	return nil
+}
+
+// HealthCheck pings the database.
+func (r *PostgresOop) HealthCheck(ctx context.Context) error {
+	return r.DB.Ping(ctx)
+}
+
+// Close closes the primary and replica pools, waiting for acquired
+// connections to be released until ctx is done.
+func (r *PostgresOop) Close(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		if set := r.replicaSet(); set != nil {
+			set.Stop()
+			for _, n := range set.Nodes() {
+				n.DB.Close()
+			}
+		}
+		r.DB.Close()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
@@ -0,0 +1,100 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/danielpnjt/go-library/dberr"
+	"github.com/danielpnjt/go-library/sqlbuilder"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Query streams the rows of a read to fn one at a time instead of loading
+// them all like Select. It stops at the first error fn returns and returns
+// that error. Unlike Select it has no built-in timeout; bound it with ctx.
+//
+// A replica that cannot be reached is marked down, and the read is retried
+// once on the primary when no row has reached fn yet.
+func (r *PostgresOop) Query(ctx context.Context, queryStatement string, args []interface{}, fn func(row map[string]interface{}) error) error {
+	db, host, node := r.reader(ctx)
+	delivered, err := r.queryFrom(ctx, db, host, queryStatement, args, fn)
+	if err != nil && r.retryOnPrimary(node, err) && delivered == 0 {
+		_, err = r.queryFrom(ctx, r.DB, r.host, queryStatement, args, fn)
+	}
+	return err
+}
+
+func (r *PostgresOop) queryFrom(ctx context.Context, db *pgxpool.Pool, host string, queryStatement string, args []interface{}, fn func(row map[string]interface{}) error) (delivered int64, err error) {
+	ctx, q := r.startQuery(ctx, "Query", queryStatement, args...)
+	q.target = host
+	defer func() {
+		delivered = q.rows
+		q.end(err)
+	}()
+
+	rows, err := db.Query(ctx, queryStatement, args...)
+	if err != nil {
+		return 0, fmt.Errorf("query failed: %w", dberr.Classify(err))
+	}
+	defer rows.Close()
+
+	fieldDescriptions := rows.FieldDescriptions()
+	columns := make([]string, len(fieldDescriptions))
+	for i, fd := range fieldDescriptions {
+		columns[i] = string(fd.Name)
+	}
+
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return 0, fmt.Errorf("error reading row: %w", dberr.Classify(err))
+		}
+
+		rowMap := make(map[string]interface{}, len(columns))
+		for i, col := range columns {
+			rowMap[col] = values[i]
+		}
+		q.rows++
+		if err := fn(rowMap); err != nil {
+			return 0, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("rows error: %w", dberr.Classify(err))
+	}
+	return 0, nil
+}
+
+// Page is one page of a keyset paginated read. NextCursor is empty on the
+// last page.
+type Page struct {
+	Rows       []map[string]interface{} `json:"rows"`
+	NextCursor string                   `json:"next_cursor,omitempty"`
+}
+
+// SelectPage reads the page of q described by k. Hand NextCursor to the
+// client and pass it back as k.Cursor for the following page.
+func (r *PostgresOop) SelectPage(ctx context.Context, q *sqlbuilder.SelectBuilder, k sqlbuilder.Keyset) (*Page, error) {
+	page, err := q.Page(k)
+	if err != nil {
+		return nil, err
+	}
+	queryStatement, args, err := page.ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.SelectContext(ctx, queryStatement, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, next, err := sqlbuilder.NextCursor(k, rows)
+	if err != nil {
+		return nil, err
+	}
+	return &Page{
+		Rows:       rows,
+		NextCursor: next,
+	}, nil
+}
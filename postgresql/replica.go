@@ -0,0 +1,108 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/danielpnjt/go-library/contextwrap"
+	"github.com/danielpnjt/go-library/dberr"
+	"github.com/danielpnjt/go-library/replica"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SetReplicas opens the read replicas in opts (host or host:port) with the
+// primary's credentials and settings. It can be called once. An
+// unreachable replica is still added and starts taking reads once its
+// health check passes.
+func (r *PostgresOop) SetReplicas(ctx context.Context, opts replica.Options) error {
+	if r.config == nil {
+		return errors.New("postgresql: replicas need a client from Init")
+	}
+	if r.replicaSet() != nil {
+		return errors.New("postgresql: replicas are already set")
+	}
+
+	pools := make([]*pgxpool.Pool, 0, len(opts.Hosts))
+	closeAll := func() {
+		for _, pool := range pools {
+			pool.Close()
+		}
+	}
+	for _, host := range opts.Hosts {
+		pool, err := r.openReplica(ctx, host)
+		if err != nil {
+			closeAll()
+			return err
+		}
+		pools = append(pools, pool)
+	}
+
+	set := replica.New(opts.Strategy, opts.CheckInterval, func(ctx context.Context, pool *pgxpool.Pool) error {
+		return pool.Ping(ctx)
+	})
+	for _, pool := range pools {
+		set.Add(ctx, pool, pool.Config().ConnConfig.Host)
+	}
+
+	r.mu.Lock()
+	r.replicas = set
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *PostgresOop) openReplica(ctx context.Context, host string) (*pgxpool.Pool, error) {
+	config := r.config.Copy()
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		port, err := strconv.ParseUint(p, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("replica %s: invalid port: %w", host, err)
+		}
+		config.ConnConfig.Host = h
+		config.ConnConfig.Port = uint16(port)
+	} else {
+		config.ConnConfig.Host = host
+	}
+	config.ConnConfig.Fallbacks = nil
+
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("replica %s: %w", host, err)
+	}
+	return pool, nil
+}
+
+func (r *PostgresOop) replicaSet() *replica.Set[*pgxpool.Pool] {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.replicas
+}
+
+// reader picks the pool for a read: a healthy replica, or the primary when
+// ctx forces it or no replica is up. node is nil for the primary.
+func (r *PostgresOop) reader(ctx context.Context) (db *pgxpool.Pool, host string, node *replica.Node[*pgxpool.Pool]) {
+	if contextwrap.GetForcePrimaryFromContext(ctx) {
+		return r.DB, r.host, nil
+	}
+	if set := r.replicaSet(); set != nil {
+		if n, ok := set.Pick(); ok {
+			return n.DB, n.Host, n
+		}
+	}
+	return r.DB, r.host, nil
+}
+
+// retryOnPrimary reports whether a read on node failed because the replica
+// could not be reached. The node is then marked down so the following
+// reads skip it, and the caller retries the read once on the primary.
+func (r *PostgresOop) retryOnPrimary(node *replica.Node[*pgxpool.Pool], err error) bool {
+	if node == nil || !errors.Is(err, dberr.ErrConnection) {
+		return false
+	}
+	if set := r.replicaSet(); set != nil {
+		set.MarkDown(node)
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/danielpnjt/go-library/tracing"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// The pool points at a closed port, so every call fails to connect; the
+// span must still be created under the caller's span and carry the error.
+func TestContextCallsCreateChildSpans(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	tracing.Init(tracing.NewOTel(tp))
+	defer tracing.Init(tracing.Noop())
+
+	r, err := Init("user", "pass", "127.0.0.1:1", "db", 1, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close(context.Background())
+
+	ctx, parent := tracing.StartSpan(context.Background(), "parent", "test")
+	if _, err := r.SelectContext(ctx, "SELECT id FROM users WHERE id = $1", 7); err == nil {
+		t.Fatal("SelectContext succeeded against a closed port")
+	}
+	if _, err := r.InsertContext(ctx, "INSERT INTO users (name) VALUES ('bob')"); err == nil {
+		t.Fatal("InsertContext succeeded against a closed port")
+	}
+	parent.End()
+
+	parentID := trace.SpanContextFromContext(ctx).SpanID()
+	want := map[string]string{
+		"Select": "SELECT id FROM users WHERE id = ?",
+		"Insert": "INSERT INTO users (name) VALUES (?)",
+	}
+	for _, s := range exp.GetSpans() {
+		statement, ok := want[s.Name]
+		if !ok {
+			continue
+		}
+		delete(want, s.Name)
+		if s.Parent.SpanID() != parentID {
+			t.Errorf("%s span is not a child of parent", s.Name)
+		}
+		if s.Status.Description == "" {
+			t.Errorf("%s span did not record the error", s.Name)
+		}
+		for _, attr := range s.Attributes {
+			if attr.Key == "db.statement" && attr.Value.AsString() != statement {
+				t.Errorf("%s db.statement = %q, want %q", s.Name, attr.Value.AsString(), statement)
+			}
+		}
+	}
+	if len(want) > 0 {
+		t.Fatalf("missing spans: %v", want)
+	}
+}
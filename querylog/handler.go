@@ -0,0 +1,24 @@
+package querylog
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Handler serves the stats table as JSON on GET and clears it on DELETE.
+// Mount it on an internal debug port only; fingerprints reveal schema.
+func (r *Recorder) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(r.Stats())
+		case http.MethodDelete:
+			r.Reset()
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Allow", "GET, DELETE")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package querylog
+
+import "strings"
+
+// mysqlDriver is the driver name the MySQL wrapper reports.
+const mysqlDriver = "mysql"
+
+// Normalize reduces a statement to its fingerprint: string and number
+// literals and placeholders become ?, lists of them collapse to a single ?,
+// comments are dropped and whitespace is squeezed. For driver "mysql"
+// double quotes delimit strings, as they do without ANSI_QUOTES; for other
+// drivers they delimit identifiers and are kept.
+func Normalize(driver, statement string) string {
+	mysql := driver == mysqlDriver
+
+	var b strings.Builder
+	b.Grow(len(statement))
+
+	space := false
+	for i := 0; i < len(statement); i++ {
+		ch := statement[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			space = b.Len() > 0
+			continue
+		case ch == '-' && i+1 < len(statement) && statement[i+1] == '-',
+			ch == '#' && mysql:
+			i = skipLine(statement, i)
+			space = b.Len() > 0
+			continue
+		case ch == '/' && i+1 < len(statement) && statement[i+1] == '*':
+			i = skipBlock(statement, i)
+			space = b.Len() > 0
+			continue
+		case ch == '\'', ch == '"' && mysql:
+			i = skipQuoted(statement, i, ch)
+			ch = '?'
+		case ch == '"' || ch == '`':
+			end := skipQuoted(statement, i, ch)
+			if space {
+				b.WriteByte(' ')
+				space = false
+			}
+			b.WriteString(statement[i : end+1])
+			i = end
+			continue
+		case ch == '$' && !mysql && dollarTag(statement, i) != "":
+			i = skipDollarQuoted(statement, i)
+			ch = '?'
+		case ch == '$' && i+1 < len(statement) && isDigit(statement[i+1]):
+			for i+1 < len(statement) && isDigit(statement[i+1]) {
+				i++
+			}
+			ch = '?'
+		case isDigit(ch) && (i == 0 || !isIdent(statement[i-1])):
+			for i+1 < len(statement) && (isDigit(statement[i+1]) || statement[i+1] == '.') {
+				i++
+			}
+			ch = '?'
+		}
+
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteByte(ch)
+	}
+	return collapseLists(b.String())
+}
+
+// skipLine returns the index of the last byte of the -- or # comment at i.
+func skipLine(s string, i int) int {
+	if j := strings.IndexByte(s[i:], '\n'); j >= 0 {
+		return i + j
+	}
+	return len(s) - 1
+}
+
+// skipBlock returns the index of the closing / of the comment at i.
+func skipBlock(s string, i int) int {
+	if j := strings.Index(s[i+2:], "*/"); j >= 0 {
+		return i + 2 + j + 1
+	}
+	return len(s) - 1
+}
+
+// dollarTag returns the opening $tag$ of a PostgreSQL dollar-quoted string
+// at i, or "" when there is none.
+func dollarTag(s string, i int) string {
+	for j := i + 1; j < len(s); j++ {
+		switch {
+		case s[j] == '$':
+			return s[i : j+1]
+		case s[j] == '_' || (s[j]|0x20 >= 'a' && s[j]|0x20 <= 'z'),
+			isDigit(s[j]) && j > i+1:
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+func skipDollarQuoted(s string, i int) int {
+	tag := dollarTag(s, i)
+	if j := strings.Index(s[i+len(tag):], tag); j >= 0 {
+		return i + len(tag) + j + len(tag) - 1
+	}
+	return len(s) - 1
+}
+
+func skipQuoted(s string, i int, quote byte) int {
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case quote:
+			if j+1 < len(s) && s[j+1] == quote {
+				j++
+				continue
+			}
+			return j
+		}
+	}
+	return len(s) - 1
+}
+
+// collapseLists turns "(?, ?, ?)" into "(?)" so IN lists and multi-row
+// VALUES of any length share one fingerprint.
+func collapseLists(s string) string {
+	for _, from := range []string{"?, ?", "?,?"} {
+		for strings.Contains(s, from) {
+			s = strings.ReplaceAll(s, from, "?")
+		}
+	}
+	for strings.Contains(s, "(?), (?)") {
+		s = strings.ReplaceAll(s, "(?), (?)", "(?)")
+	}
+	return s
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func isIdent(ch byte) bool {
+	return ch == '_' || ch == '`' || ch == '"' || isDigit(ch) || (ch|0x20 >= 'a' && ch|0x20 <= 'z')
+}
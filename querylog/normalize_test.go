@@ -0,0 +1,74 @@
+package querylog
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name   string
+		driver string
+		in     string
+		want   string
+	}{
+		{"mysql placeholders", "mysql",
+			"SELECT * FROM users WHERE id = ?",
+			"SELECT * FROM users WHERE id = ?"},
+		{"mysql single quoted string", "mysql",
+			"SELECT * FROM users WHERE email = 'a@b.c'",
+			"SELECT * FROM users WHERE email = ?"},
+		{"mysql double quoted string", "mysql",
+			`SELECT * FROM users WHERE email = "a@b.c"`,
+			"SELECT * FROM users WHERE email = ?"},
+		{"mysql escaped quotes", "mysql",
+			`UPDATE t SET a = "say \"hi\"", b = 'it''s'`,
+			"UPDATE t SET a = ?, b = ?"},
+		{"mysql backtick identifiers", "mysql",
+			"SELECT `order`, `2fa` FROM `t1` WHERE `x` = 5",
+			"SELECT `order`, `2fa` FROM `t1` WHERE `x` = ?"},
+		{"mysql hash comment", "mysql",
+			"SELECT 1 # secret 'a@b.c'\nFROM dual",
+			"SELECT ? FROM dual"},
+		{"mysql in list", "mysql",
+			"SELECT * FROM t WHERE id IN (1, 2, 3) AND s IN ('a','b')",
+			"SELECT * FROM t WHERE id IN (?) AND s IN (?)"},
+		{"mysql multi-row values", "mysql",
+			"INSERT INTO t (a, b) VALUES (?, ?), (?, ?), (?, ?)",
+			"INSERT INTO t (a, b) VALUES (?)"},
+		{"postgres placeholders", "postgresql",
+			"SELECT * FROM users WHERE id = $1 AND org = $12",
+			"SELECT * FROM users WHERE id = ? AND org = ?"},
+		{"postgres double quoted identifier", "postgresql",
+			`SELECT "user"."email", "2fa" FROM "user" WHERE "id" = 7`,
+			`SELECT "user"."email", "2fa" FROM "user" WHERE "id" = ?`},
+		{"postgres string", "postgresql",
+			"SELECT * FROM users WHERE email = 'a@b.c'",
+			"SELECT * FROM users WHERE email = ?"},
+		{"postgres dollar quoted string", "postgresql",
+			"SELECT $$a@b.c$$, $tag$it's$tag$ FROM t",
+			"SELECT ? FROM t"},
+		{"postgres hash is an operator", "postgresql",
+			"SELECT a # b FROM t",
+			"SELECT a # b FROM t"},
+		{"postgres in list", "postgresql",
+			"SELECT * FROM t WHERE id IN ($1, $2, $3)",
+			"SELECT * FROM t WHERE id IN (?)"},
+		{"line comment", "postgresql",
+			"SELECT a -- email 'a@b.c'\nFROM t",
+			"SELECT a FROM t"},
+		{"block comment", "mysql",
+			"SELECT /* user 42 */ a FROM t /* unterminated",
+			"SELECT a FROM t"},
+		{"numbers", "mysql",
+			"SELECT a1, 3.14, 42 FROM t2 LIMIT 10 OFFSET 20",
+			"SELECT a1, ? FROM t2 LIMIT ? OFFSET ?"},
+		{"whitespace", "postgresql",
+			"  SELECT\n\ta\r\n  FROM   t  ",
+			"SELECT a FROM t"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.driver, tt.in); got != tt.want {
+				t.Errorf("Normalize(%q, %q)\n got %q\nwant %q", tt.driver, tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package querylog
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danielpnjt/go-library/log"
+)
+
+const (
+	defaultMaxFingerprints = 1000
+	defaultSamples         = 512
+	// otherFingerprint collects statements once the table is full.
+	otherFingerprint = "(other)"
+	modulePrefix     = "github.com/danielpnjt/go-library/"
+)
+
+type Options struct {
+	// SlowThreshold logs statements that take at least this long. Zero
+	// disables the slow query log; stats are kept either way.
+	SlowThreshold time.Duration
+	// MaxFingerprints bounds the stats table. Defaults to 1000.
+	MaxFingerprints int
+	// Samples is how many recent durations per fingerprint the
+	// percentiles are computed from. Defaults to 512.
+	Samples int
+}
+
+// Stat is the aggregate for one statement fingerprint.
+type Stat struct {
+	Fingerprint string  `json:"fingerprint"`
+	Driver      string  `json:"driver"`
+	Count       int64   `json:"count"`
+	Errors      int64   `json:"errors"`
+	Rows        int64   `json:"rows"`
+	TotalMs     float64 `json:"total_ms"`
+	P50Ms       float64 `json:"p50_ms"`
+	P95Ms       float64 `json:"p95_ms"`
+	MaxMs       float64 `json:"max_ms"`
+}
+
+type entry struct {
+	driver  string
+	count   int64
+	errors  int64
+	rows    int64
+	total   time.Duration
+	max     time.Duration
+	samples []time.Duration
+	next    int
+}
+
+func (e *entry) add(took time.Duration, rows int64, err error, size int) {
+	e.count++
+	e.rows += rows
+	e.total += took
+	if err != nil {
+		e.errors++
+	}
+	if took > e.max {
+		e.max = took
+	}
+	if len(e.samples) < size {
+		e.samples = append(e.samples, took)
+		return
+	}
+	e.samples[e.next] = took
+	e.next = (e.next + 1) % size
+}
+
+// Recorder keeps per-fingerprint statistics and writes the slow query log.
+type Recorder struct {
+	opts    Options
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+func New(opts Options) *Recorder {
+	if opts.MaxFingerprints <= 0 {
+		opts.MaxFingerprints = defaultMaxFingerprints
+	}
+	if opts.Samples <= 0 {
+		opts.Samples = defaultSamples
+	}
+	return &Recorder{
+		opts:    opts,
+		entries: make(map[string]*entry),
+	}
+}
+
+var (
+	recorderMu sync.RWMutex
+	recorder   *Recorder
+)
+
+// Init enables the slow query log and stats for the MySQL and PostgreSQL
+// wrappers. They are off until it is called; pass nil to turn them off.
+func Init(r *Recorder) {
+	recorderMu.Lock()
+	defer recorderMu.Unlock()
+	recorder = r
+}
+
+func current() *Recorder {
+	recorderMu.RLock()
+	defer recorderMu.RUnlock()
+	return recorder
+}
+
+// Observe records a statement that started at start and finished now. rows
+// is the number of rows returned or affected.
+func Observe(driver, statement string, args []interface{}, start time.Time, rows int64, err error) {
+	if r := current(); r != nil {
+		r.Observe(driver, statement, args, time.Since(start), rows, err)
+	}
+}
+
+func (r *Recorder) Observe(driver, statement string, args []interface{}, took time.Duration, rows int64, err error) {
+	fingerprint := Normalize(driver, statement)
+
+	r.mu.Lock()
+	// Once the table is full new fingerprints share one stats entry, but
+	// the slow log below still names the real statement.
+	key := fingerprint
+	e, ok := r.entries[key]
+	if !ok {
+		if len(r.entries) >= r.opts.MaxFingerprints {
+			key = otherFingerprint
+			e = r.entries[key]
+		}
+		if e == nil {
+			e = &entry{driver: driver}
+			r.entries[key] = e
+		}
+	}
+	e.add(took, rows, err, r.opts.Samples)
+	r.mu.Unlock()
+
+	if r.opts.SlowThreshold > 0 && took >= r.opts.SlowThreshold {
+		fields := map[string]interface{}{
+			"driver":      driver,
+			"statement":   fingerprint,
+			"args":        Redact(args),
+			"duration_ms": ms(took),
+			"rows":        rows,
+			"caller":      caller(),
+		}
+		if err != nil {
+			fields["error"] = err.Error()
+		}
+		log.LogWarn("slow query", fields)
+	}
+}
+
+// Stats returns every fingerprint, slowest total time first.
+func (r *Recorder) Stats() []Stat {
+	r.mu.Lock()
+	stats := make([]Stat, 0, len(r.entries))
+	for fingerprint, e := range r.entries {
+		samples := append([]time.Duration(nil), e.samples...)
+		sort.Slice(samples, func(i, j int) bool {
+			return samples[i] < samples[j]
+		})
+		stats = append(stats, Stat{
+			Fingerprint: fingerprint,
+			Driver:      e.driver,
+			Count:       e.count,
+			Errors:      e.errors,
+			Rows:        e.rows,
+			TotalMs:     ms(e.total),
+			P50Ms:       ms(percentile(samples, 0.50)),
+			P95Ms:       ms(percentile(samples, 0.95)),
+			MaxMs:       ms(e.max),
+		})
+	}
+	r.mu.Unlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].TotalMs > stats[j].TotalMs
+	})
+	return stats
+}
+
+// Reset clears the stats table.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = make(map[string]*entry)
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[int(p*float64(len(sorted)-1)+0.5)]
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// Redact describes args by type and size without their values.
+func Redact(args []interface{}) []string {
+	out := make([]string, len(args))
+	for i, a := range args {
+		switch v := a.(type) {
+		case nil:
+			out[i] = "NULL"
+		case string:
+			out[i] = fmt.Sprintf("string(%d)", len(v))
+		case []byte:
+			out[i] = fmt.Sprintf("bytes(%d)", len(v))
+		default:
+			out[i] = fmt.Sprintf("%T", v)
+		}
+	}
+	return out
+}
+
+// caller returns file:line of the first frame outside this module.
+func caller() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, modulePrefix) && !strings.HasPrefix(frame.Function, "runtime.") {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
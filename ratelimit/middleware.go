@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	response "github.com/danielpnjt/go-library/basic"
+)
+
+// KeyFunc picks the identity a request is limited by. An empty key skips
+// limiting for that request.
+type KeyFunc func(r *http.Request) string
+
+// ByIP limits by client address, taking the first X-Forwarded-For hop when
+// present. Only use it behind a proxy that overwrites that header.
+func ByIP(r *http.Request) string {
+	if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+		ip, _, _ := strings.Cut(fwd, ",")
+		return "ip:" + strings.TrimSpace(ip)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// ByHeader limits by the value of header, e.g. an authenticated user ID set
+// by an upstream middleware.
+func ByHeader(header string) KeyFunc {
+	return func(r *http.Request) string {
+		if v := r.Header.Get(header); v != "" {
+			return "h:" + header + ":" + v
+		}
+		return ""
+	}
+}
+
+// Middleware rejects requests over the limit with 429 and sets the
+// X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset headers.
+// When Redis is unreachable requests are let through.
+func Middleware(l Limiter, key KeyFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			k := key(r)
+			if k == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			res, err := l.Allow(r.Context(), k)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(res.ResetAfter.Seconds()))))
+
+			if !res.Allowed {
+				h.Set("Retry-After", strconv.Itoa(int(math.Ceil(res.RetryAfter.Seconds()))))
+				h.Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusTooManyRequests)
+				_ = json.NewEncoder(w).Encode(&response.Response{
+					Data: new(struct{}),
+					Code: strconv.Itoa(http.StatusTooManyRequests),
+					Desc: "Too Many Requests",
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
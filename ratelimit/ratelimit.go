@@ -0,0 +1,258 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/danielpnjt/go-library/redis"
+)
+
+const defaultPrefix = "ratelimit:"
+
+// Result is the outcome of a single check.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// ResetAfter is the time until the limit is fully available again.
+	ResetAfter time.Duration
+	// RetryAfter is the time until the next request is allowed, zero when
+	// this one was.
+	RetryAfter time.Duration
+}
+
+// Limiter checks and consumes one request for key.
+type Limiter interface {
+	Allow(ctx context.Context, key string) (Result, error)
+}
+
+var fixedWindowScript = redis.NewScript(`
+local current = redis.call("incr", KEYS[1])
+if current == 1 then
+	redis.call("pexpire", KEYS[1], ARGV[1])
+end
+local ttl = redis.call("pttl", KEYS[1])
+if ttl < 0 then
+	redis.call("pexpire", KEYS[1], ARGV[1])
+	ttl = tonumber(ARGV[1])
+end
+return {current, ttl}
+`)
+
+// The sliding window and GCRA scripts read the clock with TIME so every pod
+// shares one time source; replicate_commands lets Redis < 5 accept writes
+// after TIME.
+var slidingWindowScript = redis.NewScript(`
+redis.replicate_commands()
+local t = redis.call("time")
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+
+redis.call("zremrangebyscore", KEYS[1], "-inf", now - window)
+local count = redis.call("zcard", KEYS[1])
+local allowed = 0
+if count < limit then
+	redis.call("zadd", KEYS[1], now, ARGV[3])
+	count = count + 1
+	allowed = 1
+end
+redis.call("pexpire", KEYS[1], window)
+
+local reset = window
+local oldest = redis.call("zrange", KEYS[1], 0, 0, "WITHSCORES")
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, count, reset}
+`)
+
+var gcraScript = redis.NewScript(`
+redis.replicate_commands()
+local t = redis.call("time")
+local now = tonumber(t[1]) * 1000 + tonumber(t[2]) / 1000
+local emission = tonumber(ARGV[1])
+local burst_offset = emission * tonumber(ARGV[2])
+
+local tat = tonumber(redis.call("get", KEYS[1]))
+if not tat or tat < now then
+	tat = now
+end
+
+local new_tat = tat + emission
+local diff = now - (new_tat - burst_offset)
+local remaining = math.floor(diff / emission)
+if remaining < 0 then
+	return {0, 0, math.ceil(-diff), math.ceil(tat - now)}
+end
+
+local reset = math.ceil(new_tat - now)
+redis.call("set", KEYS[1], tostring(new_tat), "PX", reset)
+return {1, remaining, 0, reset}
+`)
+
+type fixedWindow struct {
+	redis  *redis.RedisOop
+	limit  int
+	window time.Duration
+}
+
+// NewFixedWindow allows limit requests per window, counted in buckets that
+// start at the first request. Cheapest, but allows up to 2*limit across a
+// window boundary.
+func NewFixedWindow(r *redis.RedisOop, limit int, window time.Duration) (Limiter, error) {
+	if err := validate(limit, window); err != nil {
+		return nil, err
+	}
+	return &fixedWindow{redis: r, limit: limit, window: window}, nil
+}
+
+func (l *fixedWindow) Allow(ctx context.Context, key string) (Result, error) {
+	res, err := l.redis.RunScript(ctx, fixedWindowScript, []string{defaultPrefix + "fw:" + key}, l.window.Milliseconds())
+	if err != nil {
+		return Result{}, err
+	}
+	values, err := parseInts(res, 2)
+	if err != nil {
+		return Result{}, err
+	}
+
+	current, reset := int(values[0]), ms(values[1])
+	result := Result{
+		Allowed:    current <= l.limit,
+		Limit:      l.limit,
+		Remaining:  max(l.limit-current, 0),
+		ResetAfter: reset,
+	}
+	if !result.Allowed {
+		result.RetryAfter = reset
+	}
+	return result, nil
+}
+
+type slidingWindow struct {
+	redis  *redis.RedisOop
+	limit  int
+	window time.Duration
+}
+
+// NewSlidingWindow allows limit requests in any rolling window, keeping a
+// log of request times per key. Exact, at the cost of one entry per request.
+func NewSlidingWindow(r *redis.RedisOop, limit int, window time.Duration) (Limiter, error) {
+	if err := validate(limit, window); err != nil {
+		return nil, err
+	}
+	return &slidingWindow{redis: r, limit: limit, window: window}, nil
+}
+
+func (l *slidingWindow) Allow(ctx context.Context, key string) (Result, error) {
+	member, err := newMember()
+	if err != nil {
+		return Result{}, err
+	}
+
+	res, err := l.redis.RunScript(ctx, slidingWindowScript, []string{defaultPrefix + "sw:" + key}, l.window.Milliseconds(), l.limit, member)
+	if err != nil {
+		return Result{}, err
+	}
+	values, err := parseInts(res, 3)
+	if err != nil {
+		return Result{}, err
+	}
+
+	count, reset := int(values[1]), ms(values[2])
+	result := Result{
+		Allowed:    values[0] == 1,
+		Limit:      l.limit,
+		Remaining:  max(l.limit-count, 0),
+		ResetAfter: reset,
+	}
+	if !result.Allowed {
+		result.RetryAfter = reset
+	}
+	return result, nil
+}
+
+type gcra struct {
+	redis    *redis.RedisOop
+	burst    int
+	emission time.Duration
+}
+
+// NewGCRA is a token bucket refilled with rate tokens per period that holds
+// at most burst tokens, implemented with the generic cell rate algorithm so
+// only one timestamp is stored per key.
+func NewGCRA(r *redis.RedisOop, rate int, period time.Duration, burst int) (Limiter, error) {
+	if err := validate(rate, period); err != nil {
+		return nil, err
+	}
+	if burst <= 0 {
+		return nil, fmt.Errorf("ratelimit: burst must be positive, got %d", burst)
+	}
+	emission := period / time.Duration(rate)
+	if emission <= 0 {
+		return nil, fmt.Errorf("ratelimit: rate %d per %s is too fine to schedule", rate, period)
+	}
+	return &gcra{redis: r, burst: burst, emission: emission}, nil
+}
+
+func (l *gcra) Allow(ctx context.Context, key string) (Result, error) {
+	emission := float64(l.emission) / float64(time.Millisecond)
+	res, err := l.redis.RunScript(ctx, gcraScript, []string{defaultPrefix + "gcra:" + key}, emission, l.burst)
+	if err != nil {
+		return Result{}, err
+	}
+	values, err := parseInts(res, 4)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{
+		Allowed:    values[0] == 1,
+		Limit:      l.burst,
+		Remaining:  int(values[1]),
+		RetryAfter: ms(values[2]),
+		ResetAfter: ms(values[3]),
+	}, nil
+}
+
+func validate(limit int, window time.Duration) error {
+	if limit <= 0 {
+		return fmt.Errorf("ratelimit: limit must be positive, got %d", limit)
+	}
+	if window <= 0 {
+		return fmt.Errorf("ratelimit: window must be positive, got %s", window)
+	}
+	return nil
+}
+
+func parseInts(res interface{}, n int) ([]int64, error) {
+	raw, ok := res.([]interface{})
+	if !ok || len(raw) != n {
+		return nil, fmt.Errorf("ratelimit: unexpected script result %v", res)
+	}
+	values := make([]int64, n)
+	for i, v := range raw {
+		iv, ok := v.(int64)
+		if !ok {
+			return nil, fmt.Errorf("ratelimit: unexpected script result %v", res)
+		}
+		values[i] = iv
+	}
+	return values, nil
+}
+
+func ms(v int64) time.Duration {
+	return time.Duration(v) * time.Millisecond
+}
+
+func newMember() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,192 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/danielpnjt/go-library/log"
+	"github.com/redis/go-redis/v9"
+)
+
+const scanBatchSize = 500
+
+type (
+	Pipeliner = redis.Pipeliner
+	Cmder     = redis.Cmder
+)
+
+// Pipeline returns a builder that queues commands until Exec sends them in
+// one round trip.
+func (r *RedisOop) Pipeline() Pipeliner {
+	return r.redisClient.Pipeline()
+}
+
+// TxPipeline is Pipeline wrapped in MULTI/EXEC. Under Redis Cluster all keys
+// must hash to the same slot.
+func (r *RedisOop) TxPipeline() Pipeliner {
+	return r.redisClient.TxPipeline()
+}
+
+// Pipelined queues the commands issued by fn and sends them in one round trip.
+func (r *RedisOop) Pipelined(ctx context.Context, fn func(pipe Pipeliner) error) ([]Cmder, error) {
+	return r.redisClient.Pipelined(ctx, fn)
+}
+
+// TxPipelined is Pipelined wrapped in MULTI/EXEC.
+func (r *RedisOop) TxPipelined(ctx context.Context, fn func(pipe Pipeliner) error) ([]Cmder, error) {
+	return r.redisClient.TxPipelined(ctx, fn)
+}
+
+func (r *RedisOop) isCluster() bool {
+	_, ok := r.redisClient.(*redis.ClusterClient)
+	return ok
+}
+
+// MGet returns the values of keys that exist; missing keys are left out.
+func (r *RedisOop) MGet(ctx context.Context, keys ...string) (map[string]string, error) {
+	result := make(map[string]string, len(keys))
+	if len(keys) == 0 {
+		return result, nil
+	}
+
+	if r.isCluster() {
+		// MGET cannot span slots, the cluster pipeline splits GETs per node.
+		cmds := make([]*redis.StringCmd, len(keys))
+		_, err := r.redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for i, key := range keys {
+				cmds[i] = pipe.Get(ctx, key)
+			}
+			return nil
+		})
+		if err != nil && err != redis.Nil {
+			log.LogDebug("Error MGet: " + err.Error())
+			return nil, err
+		}
+		for i, cmd := range cmds {
+			if cmd.Err() == nil {
+				result[keys[i]] = cmd.Val()
+			}
+		}
+		return result, nil
+	}
+
+	values, err := r.redisClient.MGet(ctx, keys...).Result()
+	if err != nil {
+		log.LogDebug("Error MGet: " + err.Error())
+		return nil, err
+	}
+	for i, v := range values {
+		if s, ok := v.(string); ok {
+			result[keys[i]] = s
+		}
+	}
+	return result, nil
+}
+
+// MSet writes every key with the same expiration in one round trip. Outside
+// Redis Cluster the writes are applied atomically.
+func (r *RedisOop) MSet(ctx context.Context, values map[string]interface{}, expiration time.Duration) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	fn := func(pipe redis.Pipeliner) error {
+		for key, value := range values {
+			pipe.Set(ctx, key, value, expiration)
+		}
+		return nil
+	}
+
+	var err error
+	if r.isCluster() {
+		_, err = r.redisClient.Pipelined(ctx, fn)
+	} else {
+		_, err = r.redisClient.TxPipelined(ctx, fn)
+	}
+	if err != nil {
+		log.LogDebug("Error MSet: " + err.Error())
+	}
+	return err
+}
+
+// Scan calls fn for every key matching pattern, walking the keyspace with
+// SCAN so the server is never blocked the way KEYS blocks it. Under Redis
+// Cluster every master is scanned. Returning an error from fn stops the scan.
+func (r *RedisOop) Scan(ctx context.Context, pattern string, fn func(key string) error) error {
+	if cluster, ok := r.redisClient.(*redis.ClusterClient); ok {
+		var mu sync.Mutex
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return scanNode(ctx, node, pattern, func(key string) error {
+				mu.Lock()
+				defer mu.Unlock()
+				return fn(key)
+			})
+		})
+	}
+	return scanNode(ctx, r.redisClient, pattern, fn)
+}
+
+func scanNode(ctx context.Context, c redis.Cmdable, pattern string, fn func(key string) error) error {
+	iter := c.Scan(ctx, 0, pattern, scanBatchSize).Iterator()
+	for iter.Next(ctx) {
+		if err := fn(iter.Val()); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
+// DeleteByPattern removes every key matching pattern with UNLINK, which
+// frees memory in the background, and returns how many were removed.
+func (r *RedisOop) DeleteByPattern(ctx context.Context, pattern string) (int64, error) {
+	var (
+		deleted int64
+		batch   = make([]string, 0, scanBatchSize)
+	)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		n, err := r.unlink(ctx, batch)
+		deleted += n
+		batch = batch[:0]
+		return err
+	}
+
+	err := r.Scan(ctx, pattern, func(key string) error {
+		batch = append(batch, key)
+		if len(batch) < scanBatchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		log.LogDebug("Error DeleteByPattern: " + err.Error())
+	}
+	return deleted, err
+}
+
+func (r *RedisOop) unlink(ctx context.Context, keys []string) (int64, error) {
+	if !r.isCluster() {
+		return r.redisClient.Unlink(ctx, keys...).Result()
+	}
+
+	// A multi-key UNLINK cannot span slots, so send one per key.
+	cmds := make([]*redis.IntCmd, len(keys))
+	_, err := r.redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.Unlink(ctx, key)
+		}
+		return nil
+	})
+	var n int64
+	for _, cmd := range cmds {
+		n += cmd.Val()
+	}
+	return n, err
+}
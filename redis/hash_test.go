@@ -0,0 +1,69 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSetHashFieldsAppliesTTL(t *testing.T) {
+	r, mr := newTestRedisServer(t)
+	ctx := context.Background()
+
+	err := r.SetHashFields(ctx, "h", map[string]interface{}{"a": "1", "b": 2}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := mr.HGet("h", "b"); got != "2" {
+		t.Fatalf("field b = %q, want 2", got)
+	}
+	if ttl := mr.TTL("h"); ttl != time.Minute {
+		t.Fatalf("TTL = %s, want 1m", ttl)
+	}
+
+	// Without an expiration the existing TTL is kept.
+	if err := r.SetHashFields(ctx, "h", map[string]interface{}{"c": "3"}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := mr.TTL("h"); ttl != time.Minute {
+		t.Fatalf("TTL after update = %s, want 1m", ttl)
+	}
+}
+
+func TestGetHashFieldMissing(t *testing.T) {
+	r, mr := newTestRedisServer(t)
+	ctx := context.Background()
+	mr.HSet("h", "a", "1")
+
+	if v, err := r.GetHashField(ctx, "h", "a"); err != nil || v != "1" {
+		t.Fatalf("GetHashField = %q, %v", v, err)
+	}
+	if _, err := r.GetHashField(ctx, "h", "missing"); !errors.Is(err, Nil) {
+		t.Fatalf("missing field: err = %v, want Nil", err)
+	}
+	if _, err := r.GetHashField(ctx, "nokey", "a"); !errors.Is(err, Nil) {
+		t.Fatalf("missing key: err = %v, want Nil", err)
+	}
+}
+
+func TestDeleteHashFieldsRemovesEmptyKey(t *testing.T) {
+	r, mr := newTestRedisServer(t)
+	ctx := context.Background()
+	mr.HSet("h", "a", "1", "b", "2")
+
+	n, err := r.DeleteHashFields(ctx, "h", 0, "a", "missing")
+	if err != nil || n != 1 {
+		t.Fatalf("DeleteHashFields = %d, %v; want 1", n, err)
+	}
+	if !mr.Exists("h") {
+		t.Fatal("key removed while a field remained")
+	}
+
+	if _, err := r.DeleteHashFields(ctx, "h", 0, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if mr.Exists("h") {
+		t.Fatal("key still exists after deleting its last field")
+	}
+}
@@ -0,0 +1,112 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/danielpnjt/go-library/contextwrap"
+	"github.com/danielpnjt/go-library/metrics"
+	"github.com/danielpnjt/go-library/tracing"
+	"github.com/redis/go-redis/v9"
+)
+
+// instrumentHook traces every command sent through the client, so methods
+// added to RedisOop are covered without their own instrumentation.
+type instrumentHook struct {
+	target string
+}
+
+func (h *instrumentHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (h *instrumentHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		name := strings.ToUpper(cmd.Name())
+		key := commandKey(cmd)
+
+		start := time.Now()
+		spanCtx, span := tracing.StartSpan(ctx, name, "Redis")
+		span.SetAttribute("db.statement", strings.TrimSpace(name+" "+key))
+
+		err := next(spanCtx, cmd)
+		h.finish(ctx, span, name, key, start, err)
+		return err
+	}
+}
+
+func (h *instrumentHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		name := "PIPELINE"
+		names := make([]string, 0, len(cmds))
+		for _, cmd := range cmds {
+			switch n := strings.ToUpper(cmd.Name()); n {
+			case "MULTI", "EXEC":
+				name = "MULTI"
+			default:
+				names = append(names, n)
+			}
+		}
+		key := ""
+		if len(cmds) > 0 {
+			key = commandKey(cmds[0])
+			if name == "MULTI" && len(cmds) > 1 {
+				key = commandKey(cmds[1])
+			}
+		}
+
+		start := time.Now()
+		spanCtx, span := tracing.StartSpan(ctx, name, "Redis")
+		span.SetAttribute("db.statement", strings.Join(names, " "))
+
+		err := next(spanCtx, cmds)
+		if err == nil {
+			for _, cmd := range cmds {
+				if cerr := cmd.Err(); cerr != nil && cerr != redis.Nil {
+					err = cerr
+					break
+				}
+			}
+		}
+		h.finish(ctx, span, name, key, start, err)
+		return err
+	}
+}
+
+func (h *instrumentHook) finish(ctx context.Context, span tracing.Span, name, key string, start time.Time, err error) {
+	if err == redis.Nil {
+		err = nil
+	}
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+
+	metrics.Observe(metrics.ComponentRedis, name, h.target, start, err)
+
+	tr := &contextwrap.TraceRedis{
+		TraceMeta: contextwrap.NewTraceMeta(start, err),
+		Host:      h.target,
+		Command:   name,
+		Key:       key,
+	}
+	tr.Elapsed = tr.Took.String()
+	// The hook cannot hand a context back to the caller, so the entry is
+	// only kept when the request started a chain with StartTrace.
+	contextwrap.RecordTrace(ctx, tr)
+}
+
+// commandKey returns the first argument after the command name, which is
+// the key for every command this package sends.
+func commandKey(cmd redis.Cmder) string {
+	args := cmd.Args()
+	if len(args) < 2 {
+		return ""
+	}
+	return fmt.Sprint(args[1])
+}
@@ -0,0 +1,256 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultLockTTL           = 30 * time.Second
+	defaultLockRetryInterval = 100 * time.Millisecond
+)
+
+var (
+	ErrLockNotObtained = errors.New("redis: lock not obtained")
+	ErrLockNotHeld     = errors.New("redis: lock not held")
+)
+
+// newLockTicker drives auto-extension; tests replace it to tick by hand.
+var newLockTicker = func(d time.Duration) (<-chan time.Time, func()) {
+	t := time.NewTicker(d)
+	return t.C, t.Stop
+}
+
+// unlockScript deletes the key only when it still holds our token, so a
+// lock that expired and was taken by another owner is never released.
+var unlockScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0
+`)
+
+var extendScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("pexpire", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+type lockOptions struct {
+	ttl           time.Duration
+	wait          time.Duration
+	retryInterval time.Duration
+	autoExtend    bool
+}
+
+type LockOption func(*lockOptions)
+
+// WithLockWait keeps retrying every retryInterval for up to wait before
+// giving up with ErrLockNotObtained. Without it Lock tries once.
+func WithLockWait(wait time.Duration, retryInterval time.Duration) LockOption {
+	return func(o *lockOptions) {
+		o.wait = wait
+		if retryInterval > 0 {
+			o.retryInterval = retryInterval
+		}
+	}
+}
+
+// WithLockAutoExtend refreshes the lease every third of the TTL until the
+// lock is released.
+func WithLockAutoExtend() LockOption {
+	return func(o *lockOptions) {
+		o.autoExtend = true
+	}
+}
+
+// WithLockTTL sets the lease used by WithLock, 30 seconds by default.
+func WithLockTTL(ttl time.Duration) LockOption {
+	return func(o *lockOptions) {
+		o.ttl = ttl
+	}
+}
+
+// Lock is a lease on a key identified by a unique owner token.
+type Lock struct {
+	r     *RedisOop
+	key   string
+	token string
+	ttl   time.Duration
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	stopped  chan struct{}
+	lost     chan struct{}
+}
+
+// Lock acquires key for ttl. It returns ErrLockNotObtained when the key is
+// held by another owner after the configured wait.
+func (r *RedisOop) Lock(ctx context.Context, key string, ttl time.Duration, opts ...LockOption) (*Lock, error) {
+	o := lockOptions{
+		ttl:           ttl,
+		retryInterval: defaultLockRetryInterval,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.ttl <= 0 {
+		o.ttl = defaultLockTTL
+	}
+
+	token, err := newLockToken()
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(o.wait)
+	for {
+		ok, err := r.redisClient.SetNX(ctx, key, token, o.ttl).Result()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			break
+		}
+
+		if !time.Now().Add(o.retryInterval).Before(deadline) {
+			return nil, ErrLockNotObtained
+		}
+
+		timer := time.NewTimer(o.retryInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	l := &Lock{
+		r:       r,
+		key:     key,
+		token:   token,
+		ttl:     o.ttl,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+		lost:    make(chan struct{}),
+	}
+
+	if o.autoExtend {
+		go l.keepAlive()
+	} else {
+		close(l.stopped)
+	}
+
+	return l, nil
+}
+
+// WithLock runs fn while holding key, extending the lease until fn returns.
+// The ctx passed to fn is cancelled if the lease is lost.
+func (r *RedisOop) WithLock(ctx context.Context, key string, fn func(ctx context.Context) error, opts ...LockOption) error {
+	opts = append(opts, WithLockAutoExtend())
+	l, err := r.Lock(ctx, key, 0, opts...)
+	if err != nil {
+		return err
+	}
+
+	fnCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-l.Lost():
+			cancel()
+		case <-fnCtx.Done():
+		}
+	}()
+
+	fnErr := fn(fnCtx)
+
+	// Unlock even when ctx is cancelled, otherwise the key stays held
+	// until the lease runs out.
+	unlockErr := l.Unlock(context.WithoutCancel(ctx))
+	if fnErr != nil {
+		return fnErr
+	}
+	return unlockErr
+}
+
+func (l *Lock) Key() string {
+	return l.key
+}
+
+func (l *Lock) Token() string {
+	return l.token
+}
+
+// Lost is closed when automatic extension finds the lock no longer held.
+func (l *Lock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Extend resets the lease to ttl if the lock is still held by this owner.
+func (l *Lock) Extend(ctx context.Context, ttl time.Duration) error {
+	res, err := extendScript.Run(ctx, l.r.redisClient, []string{l.key}, l.token, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return err
+	}
+	if res == 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// Unlock releases the lock. It returns ErrLockNotHeld when the lease already
+// expired or the key now belongs to a different owner.
+func (l *Lock) Unlock(ctx context.Context) error {
+	l.stopOnce.Do(func() {
+		close(l.stop)
+	})
+	<-l.stopped
+
+	res, err := unlockScript.Run(ctx, l.r.redisClient, []string{l.key}, l.token).Int64()
+	if err != nil {
+		return err
+	}
+	if res == 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+func (l *Lock) keepAlive() {
+	defer close(l.stopped)
+
+	tick, stop := newLockTicker(l.ttl / 3)
+	defer stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-tick:
+			ctx, cancel := context.WithTimeout(context.Background(), l.ttl/3)
+			err := l.Extend(ctx, l.ttl)
+			cancel()
+			if errors.Is(err, ErrLockNotHeld) {
+				close(l.lost)
+				return
+			}
+		}
+	}
+}
+
+func newLockToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,132 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLockExpiresAndIsTakenByAnotherOwner(t *testing.T) {
+	r, mr := newTestRedisServer(t)
+	ctx := context.Background()
+
+	first, err := r.Lock(ctx, "job", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Lock(ctx, "job", time.Second); !errors.Is(err, ErrLockNotObtained) {
+		t.Fatalf("second Lock while held: err = %v, want ErrLockNotObtained", err)
+	}
+
+	mr.FastForward(time.Second)
+
+	second, err := r.Lock(ctx, "job", time.Second)
+	if err != nil {
+		t.Fatalf("Lock after expiry: %v", err)
+	}
+
+	// The expired owner can neither extend nor release the new owner's lock.
+	if err := first.Extend(ctx, time.Second); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("Extend by expired owner: err = %v, want ErrLockNotHeld", err)
+	}
+	if err := first.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("Unlock by expired owner: err = %v, want ErrLockNotHeld", err)
+	}
+	if got, _ := mr.Get("job"); got != second.Token() {
+		t.Fatalf("key holds %q, want the second owner's token", got)
+	}
+
+	if err := second.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if mr.Exists("job") {
+		t.Fatal("key still exists after Unlock")
+	}
+}
+
+func TestUnlockWithWrongTokenKeepsKey(t *testing.T) {
+	r, mr := newTestRedisServer(t)
+	ctx := context.Background()
+
+	l, err := r.Lock(ctx, "job", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	impostor := &Lock{
+		r:       r,
+		key:     l.Key(),
+		token:   "not-the-owner",
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+		lost:    make(chan struct{}),
+	}
+	close(impostor.stopped)
+
+	if err := impostor.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("Unlock with wrong token: err = %v, want ErrLockNotHeld", err)
+	}
+	if got, _ := mr.Get("job"); got != l.Token() {
+		t.Fatalf("key holds %q after wrong-token Unlock, want %q", got, l.Token())
+	}
+	if err := l.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLockAutoExtendOutlivesTTL(t *testing.T) {
+	r, mr := newTestRedisServer(t)
+	ctx := context.Background()
+
+	ticks := make(chan time.Time)
+	newLockTicker = func(time.Duration) (<-chan time.Time, func()) {
+		return ticks, func() {}
+	}
+	t.Cleanup(func() {
+		newLockTicker = func(d time.Duration) (<-chan time.Time, func()) {
+			tk := time.NewTicker(d)
+			return tk.C, tk.Stop
+		}
+	})
+
+	ttl := 30 * time.Second
+	l, err := r.Lock(ctx, "job", ttl, WithLockAutoExtend())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// tick resets the lease once. The keep-alive only receives the second
+	// send after the first Extend returned, so the lease is refreshed when
+	// tick returns.
+	tick := func() {
+		ticks <- time.Time{}
+		ticks <- time.Time{}
+	}
+
+	for elapsed := time.Duration(0); elapsed < 3*ttl; elapsed += 2 * ttl / 3 {
+		mr.FastForward(2 * ttl / 3)
+		if !mr.Exists("job") {
+			t.Fatalf("lock expired after %s despite auto-extend", elapsed+2*ttl/3)
+		}
+		tick()
+	}
+
+	select {
+	case <-l.Lost():
+		t.Fatal("lock reported lost while held")
+	default:
+	}
+
+	// Without a refresh the lease runs out and the next Extend reports it
+	// lost. That may be the one still in flight from the last tick.
+	mr.FastForward(ttl)
+	select {
+	case ticks <- time.Time{}:
+	case <-l.Lost():
+	}
+	<-l.Lost()
+	if err := l.Unlock(ctx); !errors.Is(err, ErrLockNotHeld) {
+		t.Fatalf("Unlock after the lease was lost: err = %v, want ErrLockNotHeld", err)
+	}
+}
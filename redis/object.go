@@ -0,0 +1,316 @@
+package redis
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielpnjt/go-library/log"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	timeType            = reflect.TypeOf(time.Time{})
+)
+
+// SetJSON stores value at key as a JSON string.
+func SetJSON[T any](ctx context.Context, r *RedisOop, key string, value T, expiration time.Duration) error {
+	js, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return r.SetRedisStringContext(ctx, key, string(js), expiration)
+}
+
+// GetJSON reads a value stored by SetJSON. It returns Nil when key is missing.
+func GetJSON[T any](ctx context.Context, r *RedisOop, key string) (T, error) {
+	var value T
+	js, err := r.GetContext(ctx, key)
+	if err != nil {
+		return value, err
+	}
+	err = json.Unmarshal([]byte(js), &value)
+	return value, err
+}
+
+// SetStruct replaces the hash at key with the exported fields of the struct
+// v points to, one hash field per json name. Scalars are stored as plain
+// strings, time.Time as RFC 3339 and nested structs, slices and maps as
+// JSON. Nil pointers are left out and read back as nil.
+func (r *RedisOop) SetStruct(ctx context.Context, key string, v interface{}, expiration time.Duration) error {
+	fields, _, err := encodeStruct(v, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		if len(fields) > 0 {
+			pipe.HSet(ctx, key, fields)
+		}
+		if expiration > 0 {
+			pipe.Expire(ctx, key, expiration)
+		}
+		return nil
+	})
+	if err != nil {
+		log.LogDebug("Error SetStruct: " + err.Error())
+	}
+	return err
+}
+
+// SetStructFields writes only the named fields (json names) of v, leaving
+// the rest of the hash untouched. A nil pointer field is removed from the
+// hash. A positive expiration is refreshed in the same MULTI/EXEC.
+func (r *RedisOop) SetStructFields(ctx context.Context, key string, v interface{}, expiration time.Duration, fields ...string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	values, nilFields, err := encodeStruct(v, fields)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if len(values) > 0 {
+			pipe.HSet(ctx, key, values)
+		}
+		if len(nilFields) > 0 {
+			pipe.HDel(ctx, key, nilFields...)
+		}
+		if expiration > 0 {
+			pipe.Expire(ctx, key, expiration)
+		}
+		return nil
+	})
+	if err != nil {
+		log.LogDebug("Error SetStructFields: " + err.Error())
+	}
+	return err
+}
+
+// GetStruct reads a hash written by SetStruct into the struct v points to.
+// Pointer fields missing from the hash are set to nil. It returns Nil when
+// key is missing.
+func (r *RedisOop) GetStruct(ctx context.Context, key string, v interface{}) error {
+	data, err := r.GetHashContext(ctx, key)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return Nil
+	}
+	return decodeStruct(data, v)
+}
+
+type structField struct {
+	name  string
+	index []int
+}
+
+// structFields lists the exported fields of t keyed like encoding/json,
+// flattening untagged embedded structs.
+func structFields(t reflect.Type, index []int) []structField {
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		idx := append(append([]int(nil), index...), i)
+
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			fields = append(fields, structFields(f.Type, idx)...)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, structField{name: name, index: idx})
+	}
+	return fields
+}
+
+func structValue(v interface{}) (reflect.Value, error) {
+	val := reflect.ValueOf(v)
+	if val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return reflect.Value{}, errors.New("redis: struct pointer is nil")
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("redis: expected a struct, got %s", val.Kind())
+	}
+	return val, nil
+}
+
+func encodeStruct(v interface{}, only []string) (map[string]interface{}, []string, error) {
+	val, err := structValue(v)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var wanted map[string]bool
+	if len(only) > 0 {
+		wanted = make(map[string]bool, len(only))
+		for _, name := range only {
+			wanted[name] = true
+		}
+	}
+
+	values := make(map[string]interface{})
+	var nilFields []string
+	for _, f := range structFields(val.Type(), nil) {
+		if wanted != nil && !wanted[f.name] {
+			continue
+		}
+		fv := val.FieldByIndex(f.index)
+		if fv.Kind() == reflect.Ptr && fv.IsNil() {
+			nilFields = append(nilFields, f.name)
+			continue
+		}
+
+		s, err := encodeValue(fv)
+		if err != nil {
+			return nil, nil, fmt.Errorf("redis: encode field %s: %w", f.name, err)
+		}
+		values[f.name] = s
+	}
+	return values, nilFields, nil
+}
+
+func encodeValue(v reflect.Value) (string, error) {
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).Format(time.RFC3339Nano), nil
+	}
+	// Check the pointer type like decodeValue does, so a type whose
+	// MarshalText has a pointer receiver round-trips as text too.
+	if reflect.PointerTo(v.Type()).Implements(textMarshalerType) {
+		if !v.CanAddr() {
+			addr := reflect.New(v.Type())
+			addr.Elem().Set(v)
+			v = addr.Elem()
+		}
+		b, err := v.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	default:
+		// Nested structs, slices, maps and interfaces keep their shape as JSON.
+		b, err := json.Marshal(v.Interface())
+		return string(b), err
+	}
+}
+
+func decodeStruct(data map[string]string, v interface{}) error {
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		return errors.New("redis: GetStruct needs a non-nil pointer to a struct")
+	}
+	val = val.Elem()
+	if val.Kind() != reflect.Struct {
+		return fmt.Errorf("redis: expected a struct, got %s", val.Kind())
+	}
+
+	for _, f := range structFields(val.Type(), nil) {
+		fv := val.FieldByIndex(f.index)
+		s, ok := data[f.name]
+		if !ok {
+			// SetStruct leaves nil pointers out of the hash, so a missing
+			// field must read back as nil rather than keep a stale value.
+			if fv.Kind() == reflect.Ptr {
+				fv.Set(reflect.Zero(fv.Type()))
+			}
+			continue
+		}
+		if err := decodeValue(s, fv); err != nil {
+			return fmt.Errorf("redis: decode field %s: %w", f.name, err)
+		}
+	}
+	return nil
+}
+
+func decodeValue(s string, v reflect.Value) error {
+	if v.Kind() == reflect.Ptr {
+		elem := reflect.New(v.Type().Elem())
+		if err := decodeValue(s, elem.Elem()); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+
+	if v.Type() == timeType {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if reflect.PointerTo(v.Type()).Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return json.Unmarshal([]byte(s), v.Addr().Interface())
+	}
+	return nil
+}
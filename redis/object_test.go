@@ -0,0 +1,62 @@
+package redis
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// upper marshals with a pointer receiver, which encodeValue must honour the
+// same way decodeValue does.
+type upper struct{ s string }
+
+func (u *upper) MarshalText() ([]byte, error) { return []byte(strings.ToUpper(u.s)), nil }
+
+func (u *upper) UnmarshalText(b []byte) error {
+	u.s = strings.ToLower(string(b))
+	return nil
+}
+
+type objectFixture struct {
+	Name  upper   `json:"name"`
+	Email *string `json:"email"`
+}
+
+func TestSetStructPointerReceiverMarshaler(t *testing.T) {
+	r, mr := newTestRedisServer(t)
+	ctx := context.Background()
+
+	in := objectFixture{Name: upper{s: "ada"}}
+	if err := r.SetStruct(ctx, "obj", &in, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := mr.HGet("obj", "name"); got != "ADA" {
+		t.Fatalf("stored name = %q, want ADA", got)
+	}
+
+	var out objectFixture
+	if err := r.GetStruct(ctx, "obj", &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name.s != "ada" {
+		t.Fatalf("decoded name = %q, want ada", out.Name.s)
+	}
+}
+
+func TestGetStructClearsMissingPointerFields(t *testing.T) {
+	r, _ := newTestRedisServer(t)
+	ctx := context.Background()
+
+	if err := r.SetStruct(ctx, "obj", &objectFixture{Name: upper{s: "ada"}}, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	stale := "old@example.com"
+	out := objectFixture{Email: &stale}
+	if err := r.GetStruct(ctx, "obj", &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Email != nil {
+		t.Fatalf("Email = %q, want nil", *out.Email)
+	}
+}
@@ -0,0 +1,115 @@
+package redis
+
+import (
+	"crypto/tls"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type Mode string
+
+const (
+	ModeStandalone Mode = "standalone"
+	ModeSentinel   Mode = "sentinel"
+	ModeCluster    Mode = "cluster"
+)
+
+// Options configures InitWithOptions. Addrs holds the server address for
+// standalone, the sentinel addresses for sentinel and the seed nodes for
+// cluster mode.
+type Options struct {
+	Mode  Mode
+	Addrs []string
+
+	// MasterName is the name of the master monitored by the sentinels.
+	MasterName       string
+	SentinelUsername string
+	SentinelPassword string
+
+	Username string
+	Password string
+	// DB is ignored by Redis Cluster, which only has database 0.
+	DB int
+
+	TLSConfig *tls.Config
+
+	PoolSize     int
+	MinIdleConns int
+
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+}
+
+// InitWithOptions connects to a standalone server, a Sentinel-managed master
+// or a Redis Cluster. RedisOop exposes the same methods in every mode.
+func InitWithOptions(opts Options) (*RedisOop, error) {
+	if len(opts.Addrs) == 0 {
+		return nil, errors.New("redis: at least one address is required")
+	}
+
+	var (
+		client redis.UniversalClient
+		target = strings.Join(opts.Addrs, ",")
+	)
+
+	switch opts.Mode {
+	case ModeStandalone, "":
+		client = redis.NewClient(&redis.Options{
+			Addr:         opts.Addrs[0],
+			Username:     opts.Username,
+			Password:     opts.Password,
+			DB:           opts.DB,
+			TLSConfig:    opts.TLSConfig,
+			PoolSize:     opts.PoolSize,
+			MinIdleConns: opts.MinIdleConns,
+			DialTimeout:  opts.DialTimeout,
+			ReadTimeout:  opts.ReadTimeout,
+			WriteTimeout: opts.WriteTimeout,
+		})
+		target = opts.Addrs[0]
+	case ModeSentinel:
+		if opts.MasterName == "" {
+			return nil, errors.New("redis: sentinel mode requires MasterName")
+		}
+		client = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       opts.MasterName,
+			SentinelAddrs:    opts.Addrs,
+			SentinelUsername: opts.SentinelUsername,
+			SentinelPassword: opts.SentinelPassword,
+			Username:         opts.Username,
+			Password:         opts.Password,
+			DB:               opts.DB,
+			TLSConfig:        opts.TLSConfig,
+			PoolSize:         opts.PoolSize,
+			MinIdleConns:     opts.MinIdleConns,
+			DialTimeout:      opts.DialTimeout,
+			ReadTimeout:      opts.ReadTimeout,
+			WriteTimeout:     opts.WriteTimeout,
+		})
+		target = opts.MasterName
+	case ModeCluster:
+		client = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        opts.Addrs,
+			Username:     opts.Username,
+			Password:     opts.Password,
+			TLSConfig:    opts.TLSConfig,
+			PoolSize:     opts.PoolSize,
+			MinIdleConns: opts.MinIdleConns,
+			DialTimeout:  opts.DialTimeout,
+			ReadTimeout:  opts.ReadTimeout,
+			WriteTimeout: opts.WriteTimeout,
+		})
+	default:
+		return nil, errors.New("redis: unknown mode " + string(opts.Mode))
+	}
+
+	client.AddHook(&instrumentHook{target: target})
+
+	return &RedisOop{
+		redisClient: client,
+	}, nil
+}
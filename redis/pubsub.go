@@ -0,0 +1,80 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/danielpnjt/go-library/log"
+	"github.com/redis/go-redis/v9"
+)
+
+type (
+	PubSub  = redis.PubSub
+	Message = redis.Message
+)
+
+// Publish sends message to every subscriber of channel.
+func (r *RedisOop) Publish(ctx context.Context, channel string, message interface{}) error {
+	return r.redisClient.Publish(ctx, channel, message).Err()
+}
+
+// Subscribe opens a subscription on channels. Messages are read from
+// PubSub.Channel, which reconnects on network errors; Close it when done.
+func (r *RedisOop) Subscribe(ctx context.Context, channels ...string) *PubSub {
+	return r.redisClient.Subscribe(ctx, channels...)
+}
+
+const (
+	minResubscribeBackoff = 100 * time.Millisecond
+	maxResubscribeBackoff = 10 * time.Second
+)
+
+// MessageHandler processes one Pub/Sub message. Errors are logged; Pub/Sub
+// has no redelivery, use streams when messages must not be lost.
+type MessageHandler func(ctx context.Context, msg *Message) error
+
+// Listen subscribes to channels and calls handler for every message until
+// ctx is done. When the subscription fails it is reopened with exponential
+// backoff, so Listen only returns ctx.Err().
+func (r *RedisOop) Listen(ctx context.Context, handler MessageHandler, channels ...string) error {
+	backoff := minResubscribeBackoff
+	for {
+		err := r.listenOnce(ctx, handler, channels, func() {
+			backoff = minResubscribeBackoff
+		})
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.LogDebug(fmt.Sprintf("Error Listen %v, resubscribing in %s: %v", channels, backoff, err))
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, maxResubscribeBackoff)
+	}
+}
+
+func (r *RedisOop) listenOnce(ctx context.Context, handler MessageHandler, channels []string, subscribed func()) error {
+	ps := r.Subscribe(ctx, channels...)
+	defer ps.Close()
+
+	if _, err := ps.Receive(ctx); err != nil {
+		return err
+	}
+	subscribed()
+
+	for {
+		msg, err := ps.ReceiveMessage(ctx)
+		if err != nil {
+			return err
+		}
+		if err := handler(ctx, msg); err != nil {
+			log.LogDebug(fmt.Sprintf("Error handling message on %s: %v", msg.Channel, err))
+		}
+	}
+}
@@ -1,14 +1,19 @@
 package redis
 
 import (
+	"context"
 	"time"
 
 	"github.com/danielpnjt/go-library/log"
-	"github.com/go-redis/redis"
+	"github.com/redis/go-redis/v9"
 )
 
+// RedisOop wraps a go-redis client. Every command starts a span, records
+// metrics and appends a contextwrap.TraceRedis entry to the trace chain of
+// the ctx it was called with.
 type RedisOop struct {
-	redisClient *redis.Client
+	redisClient redis.UniversalClient
+	ctx         context.Context
 }
 
 const (
@@ -16,18 +21,33 @@ const (
 )
 
 func Init(url string, password string) (*RedisOop, error) {
-	redClient := &RedisOop{
-		redisClient: redis.NewClient(&redis.Options{
-			Addr:     url,
-			Password: password,
-		}),
+	return InitWithOptions(Options{
+		Addrs:    []string{url},
+		Password: password,
+	})
+}
+
+// WithContext returns a copy of r whose context-less methods run under ctx.
+func (r *RedisOop) WithContext(ctx context.Context) *RedisOop {
+	return &RedisOop{
+		redisClient: r.redisClient,
+		ctx:         ctx,
 	}
+}
 
-	return redClient, nil
+func (r *RedisOop) context() context.Context {
+	if r.ctx != nil {
+		return r.ctx
+	}
+	return context.Background()
 }
 
 func (r *RedisOop) SetRedisString(key string, otp string, expiration time.Duration) error {
-	err := r.redisClient.Set(key, otp, expiration).Err()
+	return r.SetRedisStringContext(r.context(), key, otp, expiration)
+}
+
+func (r *RedisOop) SetRedisStringContext(ctx context.Context, key string, value string, expiration time.Duration) error {
+	err := r.redisClient.Set(ctx, key, value, expiration).Err()
 	if err != nil {
 		log.LogDebug("Error SetRedisString: " + err.Error())
 	}
@@ -35,7 +55,11 @@ func (r *RedisOop) SetRedisString(key string, otp string, expiration time.Durati
 }
 
 func (r *RedisOop) Get(key string) (string, error) {
-	attemptString, err := r.redisClient.Get(key).Result()
+	return r.GetContext(r.context(), key)
+}
+
+func (r *RedisOop) GetContext(ctx context.Context, key string) (string, error) {
+	attemptString, err := r.redisClient.Get(ctx, key).Result()
 	if err != nil {
 		log.LogDebug("Error GetRedis: " + err.Error())
 	}
@@ -43,16 +67,74 @@ func (r *RedisOop) Get(key string) (string, error) {
 }
 
 func (r *RedisOop) SetRedisHash(key string, objectRedis map[string]interface{}, expiration time.Duration) error {
-	err := r.redisClient.HMSet(key, objectRedis).Err()
-	r.redisClient.Expire(key, expiration)
+	return r.SetRedisHashContext(r.context(), key, objectRedis, expiration)
+}
+
+// SetRedisHashContext writes objectRedis and its expiry in one MULTI/EXEC,
+// so the hash never outlives a crash between the two.
+func (r *RedisOop) SetRedisHashContext(ctx context.Context, key string, objectRedis map[string]interface{}, expiration time.Duration) error {
+	return r.SetHashFields(ctx, key, objectRedis, expiration)
+}
+
+// SetHashFields sets fields on the hash at key. When expiration is positive
+// the TTL is (re)applied atomically with the write; otherwise the current
+// TTL of the key is left untouched.
+func (r *RedisOop) SetHashFields(ctx context.Context, key string, fields map[string]interface{}, expiration time.Duration) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	_, err := r.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, fields)
+		if expiration > 0 {
+			pipe.Expire(ctx, key, expiration)
+		}
+		return nil
+	})
 	if err != nil {
-		log.LogDebug("Error SetRedisHash: " + err.Error())
+		log.LogDebug("Error SetHashFields: " + err.Error())
 	}
 	return err
 }
 
+// GetHashField returns a single field of the hash at key, or Nil when the
+// key or field does not exist.
+func (r *RedisOop) GetHashField(ctx context.Context, key string, field string) (string, error) {
+	value, err := r.redisClient.HGet(ctx, key, field).Result()
+	if err != nil && err != Nil {
+		log.LogDebug("Error GetHashField: " + err.Error())
+	}
+	return value, err
+}
+
+// DeleteHashFields removes fields from the hash at key and returns how many
+// existed. A positive expiration refreshes the TTL in the same MULTI/EXEC.
+func (r *RedisOop) DeleteHashFields(ctx context.Context, key string, expiration time.Duration, fields ...string) (int64, error) {
+	if len(fields) == 0 {
+		return 0, nil
+	}
+
+	var deleted *redis.IntCmd
+	_, err := r.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		deleted = pipe.HDel(ctx, key, fields...)
+		if expiration > 0 {
+			pipe.Expire(ctx, key, expiration)
+		}
+		return nil
+	})
+	if err != nil {
+		log.LogDebug("Error DeleteHashFields: " + err.Error())
+		return 0, err
+	}
+	return deleted.Val(), nil
+}
+
 func (r *RedisOop) Increase(key string, field string) error {
-	err := r.redisClient.HIncrBy(key, field, 1).Err()
+	return r.IncreaseContext(r.context(), key, field)
+}
+
+func (r *RedisOop) IncreaseContext(ctx context.Context, key string, field string) error {
+	err := r.redisClient.HIncrBy(ctx, key, field, 1).Err()
 	if err != nil {
 		log.LogDebug("Error Increase: " + err.Error())
 	}
@@ -60,7 +142,11 @@ func (r *RedisOop) Increase(key string, field string) error {
 }
 
 func (r *RedisOop) Delete(key string) error {
-	err := r.redisClient.Del(key).Err()
+	return r.DeleteContext(r.context(), key)
+}
+
+func (r *RedisOop) DeleteContext(ctx context.Context, key string) error {
+	err := r.redisClient.Del(ctx, key).Err()
 	if err != nil {
 		log.LogDebug("Error Delete: " + err.Error())
 	}
@@ -68,7 +154,11 @@ func (r *RedisOop) Delete(key string) error {
 }
 
 func (r *RedisOop) GetHash(key string) (map[string]string, error) {
-	data, err := r.redisClient.HGetAll(key).Result()
+	return r.GetHashContext(r.context(), key)
+}
+
+func (r *RedisOop) GetHashContext(ctx context.Context, key string) (map[string]string, error) {
+	data, err := r.redisClient.HGetAll(ctx, key).Result()
 	if err != nil {
 		log.LogDebug("Error GetHash: " + err.Error())
 	}
@@ -77,7 +167,11 @@ func (r *RedisOop) GetHash(key string) (map[string]string, error) {
 }
 
 func (r *RedisOop) GetTTLInSecond(key string) (int, error) {
-	cd, err := r.redisClient.TTL(key).Result()
+	return r.GetTTLInSecondContext(r.context(), key)
+}
+
+func (r *RedisOop) GetTTLInSecondContext(ctx context.Context, key string) (int, error) {
+	cd, err := r.redisClient.TTL(ctx, key).Result()
 	inSecond := int(cd.Seconds())
 
 	if err != nil {
@@ -87,10 +181,24 @@ func (r *RedisOop) GetTTLInSecond(key string) (int, error) {
 }
 
 func (r *RedisOop) IncreaseByKey(key string) (int64, error) {
-	num, err := r.redisClient.Incr(key).Result()
+	return r.IncreaseByKeyContext(r.context(), key)
+}
+
+func (r *RedisOop) IncreaseByKeyContext(ctx context.Context, key string) (int64, error) {
+	num, err := r.redisClient.Incr(ctx, key).Result()
 	if err != nil {
 		log.LogDebug("Error IncreaseByKey: " + err.Error())
 	}
 
 	return num, err
 }
+
+// HealthCheck sends PING to the server.
+func (r *RedisOop) HealthCheck(ctx context.Context) error {
+	return r.redisClient.Ping(ctx).Err()
+}
+
+// Close releases every pooled connection.
+func (r *RedisOop) Close(ctx context.Context) error {
+	return r.redisClient.Close()
+}
@@ -0,0 +1,32 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// newTestRedis connects a RedisOop to an in-memory server that is shut
+// down with the test.
+func newTestRedis(t *testing.T) *RedisOop {
+	t.Helper()
+	r, _ := newTestRedisServer(t)
+	return r
+}
+
+// newTestRedisServer is newTestRedis that also returns the server, for
+// tests that inspect or manipulate it directly.
+func newTestRedisServer(t *testing.T) (*RedisOop, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	r, err := Init(mr.Addr(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		r.Close(context.Background())
+	})
+	return r, mr
+}
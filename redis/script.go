@@ -0,0 +1,25 @@
+package redis
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Script is a Lua script sent with EVALSHA, falling back to EVAL the first
+// time a server has not cached it.
+type Script struct {
+	script *redis.Script
+}
+
+func NewScript(src string) *Script {
+	return &Script{
+		script: redis.NewScript(src),
+	}
+}
+
+// RunScript runs s atomically on the server. Under Redis Cluster every key
+// must hash to the same slot; use a {hash tag} when keys are related.
+func (r *RedisOop) RunScript(ctx context.Context, s *Script, keys []string, args ...interface{}) (interface{}, error) {
+	return s.script.Run(ctx, r.redisClient, keys, args...).Result()
+}
@@ -0,0 +1,274 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielpnjt/go-library/contextwrap"
+	"github.com/danielpnjt/go-library/log"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultStreamCount         = 10
+	defaultStreamBlock         = 5 * time.Second
+	defaultStreamMinIdle       = time.Minute
+	defaultStreamClaimInterval = 30 * time.Second
+	defaultStreamMaxDeliveries = 5
+	deadLetterSuffix           = ":dead"
+)
+
+type StreamMessage = redis.XMessage
+
+// StreamHandler processes one stream entry. Returning nil acknowledges it;
+// an error leaves it pending so it is redelivered after ConsumerOptions.MinIdle.
+type StreamHandler func(ctx context.Context, msg StreamMessage) error
+
+type ConsumerOptions struct {
+	Stream   string
+	Group    string
+	Consumer string
+	// Count is the batch size of every read, 10 by default.
+	Count int64
+	// Block is how long a read waits for new entries, 5 seconds by default.
+	Block time.Duration
+	// MinIdle is how long an entry stays pending before it is reclaimed
+	// from a consumer that failed or died, one minute by default.
+	MinIdle time.Duration
+	// ClaimInterval is how often pending entries are reclaimed.
+	ClaimInterval time.Duration
+	// MaxDeliveries moves an entry to DeadLetterStream once it has been
+	// delivered more often than this, 5 by default.
+	MaxDeliveries int64
+	// DeadLetterStream defaults to Stream + ":dead".
+	DeadLetterStream string
+}
+
+// StreamAdd appends values to stream and returns the entry ID. A positive
+// maxLen trims the stream to roughly that many entries.
+func (r *RedisOop) StreamAdd(ctx context.Context, stream string, values map[string]interface{}, maxLen int64) (string, error) {
+	args := &redis.XAddArgs{
+		Stream: stream,
+		Values: values,
+	}
+	if maxLen > 0 {
+		args.MaxLen = maxLen
+		args.Approx = true
+	}
+
+	start := time.Now()
+	id, err := r.redisClient.XAdd(ctx, args).Result()
+	if err != nil {
+		log.LogDebug("Error StreamAdd: " + err.Error())
+	}
+
+	tr := &contextwrap.TraceQueue{
+		TraceMeta:   contextwrap.NewTraceMeta(start, err),
+		Broker:      "redis",
+		Operation:   "publish",
+		Destination: stream,
+		MessageID:   id,
+	}
+	tr.Elapsed = tr.Took.String()
+	contextwrap.RecordTrace(ctx, tr)
+	return id, err
+}
+
+// StreamCreateGroup creates group on stream, creating the stream if needed.
+// start is "$" for new entries only or "0" for the whole stream. An existing
+// group is not an error.
+func (r *RedisOop) StreamCreateGroup(ctx context.Context, stream string, group string, start string) error {
+	err := r.redisClient.XGroupCreateMkStream(ctx, stream, group, start).Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil
+	}
+	return err
+}
+
+func (r *RedisOop) StreamAck(ctx context.Context, stream string, group string, ids ...string) error {
+	return r.redisClient.XAck(ctx, stream, group, ids...).Err()
+}
+
+// Consume reads stream through a consumer group and calls handler for every
+// entry until ctx is done. Entries left pending by any consumer are
+// reclaimed with XAUTOCLAIM, and entries that keep failing are moved to the
+// dead-letter stream.
+func (r *RedisOop) Consume(ctx context.Context, opts ConsumerOptions, handler StreamHandler) error {
+	if opts.Stream == "" || opts.Group == "" || opts.Consumer == "" {
+		return errors.New("redis: Consume requires Stream, Group and Consumer")
+	}
+	if opts.Count <= 0 {
+		opts.Count = defaultStreamCount
+	}
+	if opts.Block <= 0 {
+		opts.Block = defaultStreamBlock
+	}
+	if opts.MinIdle <= 0 {
+		opts.MinIdle = defaultStreamMinIdle
+	}
+	if opts.ClaimInterval <= 0 {
+		opts.ClaimInterval = defaultStreamClaimInterval
+	}
+	if opts.MaxDeliveries <= 0 {
+		opts.MaxDeliveries = defaultStreamMaxDeliveries
+	}
+	if opts.DeadLetterStream == "" {
+		opts.DeadLetterStream = opts.Stream + deadLetterSuffix
+	}
+
+	if err := r.StreamCreateGroup(ctx, opts.Stream, opts.Group, "$"); err != nil {
+		return err
+	}
+
+	// Reclaim first so entries left by a previous run of this consumer are
+	// not stuck until the first interval passes.
+	nextClaim := time.Now()
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if !time.Now().Before(nextClaim) {
+			if err := r.reclaim(ctx, opts, handler); err != nil && ctx.Err() == nil {
+				log.LogDebug("Error reclaiming stream " + opts.Stream + ": " + err.Error())
+			}
+			nextClaim = time.Now().Add(opts.ClaimInterval)
+		}
+
+		streams, err := r.redisClient.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    opts.Group,
+			Consumer: opts.Consumer,
+			Streams:  []string{opts.Stream, ">"},
+			Count:    opts.Count,
+			Block:    opts.Block,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.LogDebug("Error reading stream " + opts.Stream + ": " + err.Error())
+			sleepContext(ctx, time.Second)
+			continue
+		}
+
+		for _, s := range streams {
+			for _, msg := range s.Messages {
+				r.handleStreamMessage(ctx, opts, handler, msg)
+			}
+		}
+	}
+}
+
+func (r *RedisOop) reclaim(ctx context.Context, opts ConsumerOptions, handler StreamHandler) error {
+	start := "0-0"
+	for {
+		msgs, next, err := r.redisClient.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   opts.Stream,
+			Group:    opts.Group,
+			Consumer: opts.Consumer,
+			MinIdle:  opts.MinIdle,
+			Start:    start,
+			Count:    opts.Count,
+		}).Result()
+		if err != nil {
+			return err
+		}
+
+		if len(msgs) > 0 {
+			deliveries, err := r.deliveryCounts(ctx, opts, msgs)
+			if err != nil {
+				return err
+			}
+
+			for _, msg := range msgs {
+				if deliveries[msg.ID] > opts.MaxDeliveries {
+					if err := r.deadLetter(ctx, opts, msg, deliveries[msg.ID]); err != nil {
+						return err
+					}
+					continue
+				}
+				r.handleStreamMessage(ctx, opts, handler, msg)
+			}
+		}
+
+		if next == "0-0" || next == "" {
+			return nil
+		}
+		start = next
+	}
+}
+
+// deliveryCounts looks every ID up on its own: a range query would also
+// return other pending entries of the consumer that fall between the
+// claimed IDs, crowd some claimed ones out of the count, and let them
+// escape the dead letters.
+func (r *RedisOop) deliveryCounts(ctx context.Context, opts ConsumerOptions, msgs []StreamMessage) (map[string]int64, error) {
+	cmds := make([]*redis.XPendingExtCmd, len(msgs))
+	_, err := r.redisClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, msg := range msgs {
+			cmds[i] = pipe.XPendingExt(ctx, &redis.XPendingExtArgs{
+				Stream: opts.Stream,
+				Group:  opts.Group,
+				Start:  msg.ID,
+				End:    msg.ID,
+				Count:  1,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(msgs))
+	for _, cmd := range cmds {
+		for _, p := range cmd.Val() {
+			counts[p.ID] = p.RetryCount
+		}
+	}
+	return counts, nil
+}
+
+func (r *RedisOop) deadLetter(ctx context.Context, opts ConsumerOptions, msg StreamMessage, deliveries int64) error {
+	values := make(map[string]interface{}, len(msg.Values)+4)
+	for k, v := range msg.Values {
+		values[k] = v
+	}
+	values["dead_letter_stream"] = opts.Stream
+	values["dead_letter_group"] = opts.Group
+	values["dead_letter_id"] = msg.ID
+	values["dead_letter_deliveries"] = strconv.FormatInt(deliveries, 10)
+
+	// Not a MULTI: the dead-letter stream may live in another cluster slot.
+	// A crash between the two only duplicates the entry in the dead letters.
+	if err := r.redisClient.XAdd(ctx, &redis.XAddArgs{Stream: opts.DeadLetterStream, Values: values}).Err(); err != nil {
+		return err
+	}
+	return r.StreamAck(ctx, opts.Stream, opts.Group, msg.ID)
+}
+
+func (r *RedisOop) handleStreamMessage(ctx context.Context, opts ConsumerOptions, handler StreamHandler, msg StreamMessage) {
+	if err := handler(ctx, msg); err != nil {
+		log.LogDebug(fmt.Sprintf("Error handling stream %s entry %s: %v", opts.Stream, msg.ID, err))
+		return
+	}
+	if err := r.StreamAck(ctx, opts.Stream, opts.Group, msg.ID); err != nil {
+		log.LogDebug(fmt.Sprintf("Error acking stream %s entry %s: %v", opts.Stream, msg.ID, err))
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
@@ -0,0 +1,86 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestReclaimDeadLettersInterleavedPendingEntries(t *testing.T) {
+	r, mr := newTestRedisServer(t)
+	ctx := context.Background()
+	mr.SetTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	opts := ConsumerOptions{
+		Stream:           "jobs",
+		Group:            "workers",
+		Consumer:         "c1",
+		Count:            10,
+		MinIdle:          time.Minute,
+		MaxDeliveries:    5,
+		DeadLetterStream: "jobs:dead",
+	}
+
+	var ids []string
+	for i := 0; i < 5; i++ {
+		id, err := r.StreamAdd(ctx, opts.Stream, map[string]interface{}{"n": i}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if err := r.StreamCreateGroup(ctx, opts.Stream, opts.Group, "0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.redisClient.Do(ctx, "XREADGROUP", "GROUP", opts.Group, opts.Consumer, "COUNT", 10, "STREAMS", opts.Stream, ">").Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Entries 0, 2 and 4 are poison: idle and delivered too often. Entries
+	// 1 and 3 are pending on the same consumer between them but were just
+	// delivered, so XAUTOCLAIM leaves them alone.
+	for i, id := range ids {
+		args := []interface{}{"XCLAIM", opts.Stream, opts.Group, opts.Consumer, 0, id}
+		if i%2 == 0 {
+			args = append(args, "IDLE", time.Hour.Milliseconds(), "RETRYCOUNT", opts.MaxDeliveries)
+		} else {
+			args = append(args, "IDLE", 0)
+		}
+		if err := r.redisClient.Do(ctx, args...).Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	handled := map[string]bool{}
+	err := r.reclaim(ctx, opts, func(ctx context.Context, msg StreamMessage) error {
+		handled[msg.ID] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(handled) > 0 {
+		t.Fatalf("handler ran for %v, want every claimed entry dead-lettered", handled)
+	}
+	dead, err := r.redisClient.XRange(ctx, opts.DeadLetterStream, "-", "+").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dead) != 3 {
+		t.Fatalf("got %d dead letters, want 3", len(dead))
+	}
+	for i, msg := range dead {
+		if got, want := msg.Values["dead_letter_id"], ids[i*2]; got != want {
+			t.Errorf("dead letter %d is %v, want %s", i, got, want)
+		}
+	}
+
+	pending, err := r.redisClient.XPending(ctx, opts.Stream, opts.Group).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending.Count != 2 {
+		t.Fatalf("pending = %d, want the 2 fresh entries", pending.Count)
+	}
+}
@@ -0,0 +1,45 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/danielpnjt/go-library/tracing"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestCommandsCreateChildSpans(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	tracing.Init(tracing.NewOTel(tp))
+	defer tracing.Init(tracing.Noop())
+
+	r := newTestRedis(t)
+
+	ctx, parent := tracing.StartSpan(context.Background(), "parent", "test")
+	if err := r.SetRedisStringContext(ctx, "k", "v", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.GetContext(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	parentID := trace.SpanContextFromContext(ctx).SpanID()
+	want := map[string]bool{"SET": true, "GET": true}
+	for _, s := range exp.GetSpans() {
+		if !want[s.Name] {
+			continue
+		}
+		delete(want, s.Name)
+		if s.Parent.SpanID() != parentID {
+			t.Errorf("%s span is not a child of parent", s.Name)
+		}
+	}
+	if len(want) > 0 {
+		t.Fatalf("missing spans: %v", want)
+	}
+}
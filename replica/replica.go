@@ -0,0 +1,185 @@
+package replica
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Strategy int
+
+const (
+	// RoundRobin spreads reads evenly over the healthy replicas.
+	RoundRobin Strategy = iota
+	// LeastLatency sends reads to the healthy replica with the lowest
+	// smoothed ping time.
+	LeastLatency
+)
+
+const (
+	defaultInterval = 5 * time.Second
+	pingTimeout     = 2 * time.Second
+)
+
+// Options configures the read replicas of a database client.
+type Options struct {
+	// Hosts are the replica addresses. They share every other setting
+	// with the primary.
+	Hosts    []string
+	Strategy Strategy
+	// CheckInterval is how often replicas are pinged. Defaults to five
+	// seconds.
+	CheckInterval time.Duration
+}
+
+// Node is one replica pool.
+type Node[T any] struct {
+	DB      T
+	Host    string
+	healthy atomic.Bool
+	latency atomic.Int64
+}
+
+func (n *Node[T]) Healthy() bool {
+	return n.healthy.Load()
+}
+
+// Latency is the smoothed ping time of the node.
+func (n *Node[T]) Latency() time.Duration {
+	return time.Duration(n.latency.Load())
+}
+
+func (n *Node[T]) observe(took time.Duration, err error) {
+	if err != nil {
+		n.healthy.Store(false)
+		return
+	}
+	n.healthy.Store(true)
+
+	// Exponential moving average weighting the newest sample by 1/4.
+	prev := n.latency.Load()
+	if prev == 0 {
+		n.latency.Store(int64(took))
+		return
+	}
+	n.latency.Store(prev + (int64(took)-prev)/4)
+}
+
+// Set tracks the health of a group of replicas and picks one per read.
+// A background loop pings every node; unhealthy nodes are skipped until a
+// ping succeeds again.
+type Set[T any] struct {
+	mu       sync.RWMutex
+	nodes    []*Node[T]
+	next     atomic.Uint64
+	strategy Strategy
+	ping     func(ctx context.Context, db T) error
+	interval time.Duration
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stop      chan struct{}
+}
+
+// New returns an empty set. ping checks one node; interval is how often
+// nodes are pinged, five seconds when zero.
+func New[T any](strategy Strategy, interval time.Duration, ping func(ctx context.Context, db T) error) *Set[T] {
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	return &Set[T]{
+		strategy: strategy,
+		ping:     ping,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Add pings db once and adds it to the set, healthy or not, then makes
+// sure the health loop is running.
+func (s *Set[T]) Add(ctx context.Context, db T, host string) *Node[T] {
+	n := &Node[T]{DB: db, Host: host}
+	s.check(ctx, n)
+
+	s.mu.Lock()
+	s.nodes = append(s.nodes, n)
+	s.mu.Unlock()
+
+	s.startOnce.Do(func() {
+		go s.loop()
+	})
+	return n
+}
+
+// Nodes returns every node, healthy or not.
+func (s *Set[T]) Nodes() []*Node[T] {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]*Node[T](nil), s.nodes...)
+}
+
+// Pick returns a healthy node, or false when there is none.
+func (s *Set[T]) Pick() (*Node[T], bool) {
+	s.mu.RLock()
+	healthy := make([]*Node[T], 0, len(s.nodes))
+	for _, n := range s.nodes {
+		if n.Healthy() {
+			healthy = append(healthy, n)
+		}
+	}
+	s.mu.RUnlock()
+
+	if len(healthy) == 0 {
+		return nil, false
+	}
+
+	if s.strategy == LeastLatency {
+		best := healthy[0]
+		for _, n := range healthy[1:] {
+			if n.Latency() < best.Latency() {
+				best = n
+			}
+		}
+		return best, true
+	}
+	return healthy[s.next.Add(1)%uint64(len(healthy))], true
+}
+
+// MarkDown takes n out of rotation after a read on it failed to reach the
+// server. The health loop brings it back after its next successful ping.
+func (s *Set[T]) MarkDown(n *Node[T]) {
+	n.healthy.Store(false)
+}
+
+func (s *Set[T]) check(ctx context.Context, n *Node[T]) {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := s.ping(ctx, n.DB)
+	n.observe(time.Since(start), err)
+}
+
+func (s *Set[T]) loop() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			for _, n := range s.Nodes() {
+				s.check(context.Background(), n)
+			}
+		}
+	}
+}
+
+// Stop ends the health loop. Closing the node pools is left to the caller.
+func (s *Set[T]) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+}
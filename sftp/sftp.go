@@ -5,16 +5,23 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sync"
 	"time"
 
+	"github.com/danielpnjt/go-library/contextwrap"
+	"github.com/danielpnjt/go-library/metrics"
+	"github.com/danielpnjt/go-library/tracing"
 	"github.com/pkg/sftp"
-	"go.elastic.co/apm"
 	"golang.org/x/crypto/ssh"
 )
 
 type SftpOop struct {
+	mu         sync.Mutex
 	sftpClient *sftp.Client
 	Conn       *ssh.Client
+	host       string
+	closed     chan struct{}
+	closeOnce  sync.Once
 }
 
 func Init(user string, pass string, host string, port string) (*SftpOop, error) {
@@ -39,6 +46,8 @@ func Init(user string, pass string, host string, port string) (*SftpOop, error)
 	sftpCurrent := &SftpOop{
 		sftpClient: sftpClientNew,
 		Conn:       conn,
+		host:       host,
+		closed:     make(chan struct{}),
 	}
 
 	go HandleReconnect(sftpCurrent, user, pass, host, port)
@@ -49,11 +58,22 @@ func Init(user string, pass string, host string, port string) (*SftpOop, error)
 func HandleReconnect(sftpCurrent *SftpOop, user, pass, host, port string) (*SftpOop, error) {
 	closed := make(chan string)
 
+	sftpCurrent.mu.Lock()
+	currentConn := sftpCurrent.Conn
+	sftpCurrent.mu.Unlock()
+
 	go func() {
-		closed <- sftpCurrent.Conn.Wait().Error()
+		if err := currentConn.Wait(); err != nil {
+			closed <- err.Error()
+			return
+		}
+		closed <- "connection closed"
 	}()
 
 	errMsg := <-closed
+	if sftpCurrent.isClosed() {
+		return sftpCurrent, nil
+	}
 	fmt.Println("closed message:", errMsg)
 
 	conf := &ssh.ClientConfig{
@@ -76,8 +96,17 @@ func HandleReconnect(sftpCurrent *SftpOop, user, pass, host, port string) (*Sftp
 		return nil, err
 	}
 
+	sftpCurrent.mu.Lock()
+	if sftpCurrent.isClosed() {
+		// Close raced with the reconnection, drop the new connection.
+		sftpCurrent.mu.Unlock()
+		sftpClientNew.Close()
+		conn.Close()
+		return sftpCurrent, nil
+	}
 	sftpCurrent.Conn = conn
 	sftpCurrent.sftpClient = sftpClientNew
+	sftpCurrent.mu.Unlock()
 
 	fmt.Println("SFTP reconnection success on:", time.Now().String())
 
@@ -86,38 +115,99 @@ func HandleReconnect(sftpCurrent *SftpOop, user, pass, host, port string) (*Sftp
 	return sftpCurrent, nil
 }
 
+func (s *SftpOop) isClosed() bool {
+	select {
+	case <-s.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *SftpOop) client() *sftp.Client {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sftpClient
+}
+
+// Close closes the SFTP session and the SSH connection and stops
+// HandleReconnect from dialing again.
+func (s *SftpOop) Close(ctx context.Context) error {
+	var err error
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if s.closed != nil {
+			close(s.closed)
+		}
+		if cerr := s.sftpClient.Close(); cerr != nil {
+			err = cerr
+		}
+		if cerr := s.Conn.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	})
+	return err
+}
+
 func (s *SftpOop) SendLocalFileToRemote(ctx context.Context, localpath, remotepath string) (int, error) {
-	apmSpan, _ := apm.StartSpan(ctx, "Send Local File to Remote", "SFTP")
-	defer apmSpan.End()
+	start := time.Now()
+	_, span := tracing.StartSpan(ctx, "Send Local File to Remote", "SFTP")
+	defer span.End()
 
 	count, err := s.sendFile(remotepath, localpath)
 	if err != nil {
+		span.RecordError(err)
+		s.record(ctx, "SendLocalFileToRemote", remotepath, 0, start, err)
 		return 0, err
 	}
 
+	s.record(ctx, "SendLocalFileToRemote", remotepath, count, start, nil)
 	return count, nil
 }
 
 func (s *SftpOop) SendLocalFileToRemoteWithDelete(ctx context.Context, localpath, remotepath string) (int, error) {
-	apmSpan, _ := apm.StartSpan(ctx, "Send&Delete Local File to Remote", "SFTP")
-	defer apmSpan.End()
+	start := time.Now()
+	_, span := tracing.StartSpan(ctx, "Send&Delete Local File to Remote", "SFTP")
+	defer span.End()
 
 	count, err := s.sendFile(remotepath, localpath)
 	if err != nil {
+		span.RecordError(err)
+		s.record(ctx, "SendLocalFileToRemoteWithDelete", remotepath, 0, start, err)
 		return 0, err
 	}
 
 	err = os.Remove(localpath)
 	if err != nil {
 		fmt.Println("error on deletion local file : ", err)
+		span.RecordError(err)
+		s.record(ctx, "SendLocalFileToRemoteWithDelete", remotepath, count, start, err)
 		return 0, err
 	}
 
+	s.record(ctx, "SendLocalFileToRemoteWithDelete", remotepath, count, start, nil)
 	return count, nil
 }
 
+// record reports a finished transfer to metrics and the request trace.
+func (s *SftpOop) record(ctx context.Context, op, path string, bytes int, start time.Time, err error) {
+	metrics.Observe(metrics.ComponentSftp, op, s.host, start, err)
+
+	tr := &contextwrap.TraceSftp{
+		TraceMeta: contextwrap.NewTraceMeta(start, err),
+		Host:      s.host,
+		Operation: op,
+		Path:      path,
+		Bytes:     int64(bytes),
+	}
+	tr.Elapsed = tr.Took.String()
+	contextwrap.RecordTrace(ctx, tr)
+}
+
 func (s *SftpOop) sendFile(remotepath, localpath string) (int, error) {
-	remoteFile, err := s.sftpClient.Create(remotepath)
+	remoteFile, err := s.client().Create(remotepath)
 	if err != nil {
 		fmt.Println("error on creating pipeline to remote hhost : ", err)
 		return 0, err
@@ -147,5 +237,23 @@ func (s *SftpOop) sendFile(remotepath, localpath string) (int, error) {
 }
 
 func (s *SftpOop) GetConnection(ctx context.Context) *ssh.Client {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.Conn
 }
+
+// HealthCheck stats the remote root over the current connection.
+func (s *SftpOop) HealthCheck(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.client().Stat("/")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
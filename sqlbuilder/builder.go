@@ -0,0 +1,171 @@
+// Package sqlbuilder builds parameterized statements for the MySQL and
+// PostgreSQL wrappers:
+//
+//	query, args, err := sqlbuilder.Postgres.Select("id", "name").
+//		From("users").
+//		Where(sqlbuilder.Eq("status", "active"), sqlbuilder.If(name != "", sqlbuilder.Like("name", name+"%"))).
+//		OrderBy("id").
+//		Limit(20).
+//		ToSQL()
+//	rows, err := db.SelectContext(ctx, query, args...)
+package sqlbuilder
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dialect decides placeholder and identifier quoting style.
+type Dialect int
+
+const (
+	// MySQL uses ? placeholders and `backtick` identifiers.
+	MySQL Dialect = iota
+	// Postgres uses $n placeholders and "double quoted" identifiers.
+	Postgres
+)
+
+var (
+	errNoTable   = errors.New("sqlbuilder: no table")
+	errNoColumns = errors.New("sqlbuilder: no columns")
+	errNoWhere   = errors.New("sqlbuilder: no WHERE condition; call All to affect every row")
+)
+
+// Quote quotes an identifier, quoting each part of a dotted name
+// separately. "*" and the part after a trailing ".*" are left bare.
+func (d Dialect) Quote(ident string) string {
+	q := "`"
+	if d == Postgres {
+		q = `"`
+	}
+
+	parts := strings.Split(ident, ".")
+	for i, p := range parts {
+		if p == "*" {
+			continue
+		}
+		parts[i] = q + strings.ReplaceAll(p, q, q+q) + q
+	}
+	return strings.Join(parts, ".")
+}
+
+// Raw is an SQL fragment inserted as is. Its ? placeholders are renumbered
+// for Postgres. Use it for expressions such as COUNT(*) or NOW() wherever a
+// column or value is expected.
+type Raw struct {
+	SQL  string
+	Args []interface{}
+}
+
+func Expr(sql string, args ...interface{}) Raw {
+	return Raw{SQL: sql, Args: args}
+}
+
+// buf accumulates SQL text and arguments for one statement.
+type buf struct {
+	dialect Dialect
+	sb      strings.Builder
+	args    []interface{}
+	// err is the first build error, returned by result.
+	err error
+}
+
+func (b *buf) write(s string) {
+	b.sb.WriteString(s)
+}
+
+func (b *buf) ident(name string) {
+	b.sb.WriteString(b.dialect.Quote(name))
+}
+
+func (b *buf) idents(names []string) {
+	for i, name := range names {
+		if i > 0 {
+			b.write(", ")
+		}
+		b.ident(name)
+	}
+}
+
+// arg writes a placeholder for v, or v itself when it is a Raw.
+func (b *buf) arg(v interface{}) {
+	if r, ok := v.(Raw); ok {
+		b.raw(r)
+		return
+	}
+	b.args = append(b.args, v)
+	if b.dialect == Postgres {
+		b.write("$" + strconv.Itoa(len(b.args)))
+		return
+	}
+	b.write("?")
+}
+
+func (b *buf) raw(r Raw) {
+	if b.dialect != Postgres {
+		b.write(r.SQL)
+		b.args = append(b.args, r.Args...)
+		return
+	}
+
+	rest := r.SQL
+	for _, a := range r.Args {
+		i := strings.IndexByte(rest, '?')
+		if i < 0 {
+			break
+		}
+		b.write(rest[:i])
+		b.arg(a)
+		rest = rest[i+1:]
+	}
+	b.write(rest)
+}
+
+// column writes a quoted column, or the fragment when it is a Raw.
+func (b *buf) column(c interface{}) {
+	switch v := c.(type) {
+	case Raw:
+		b.raw(v)
+	case string:
+		b.ident(v)
+	default:
+		if b.err == nil {
+			b.err = fmt.Errorf("sqlbuilder: column must be a string or Raw, got %T", c)
+		}
+	}
+}
+
+func (b *buf) where(conds []Cond) {
+	if c := And(conds...); c != nil {
+		b.write(" WHERE ")
+		c.build(b)
+	}
+}
+
+type order struct {
+	column string
+	desc   bool
+}
+
+func (b *buf) orderBy(orders []order) {
+	for i, o := range orders {
+		if i == 0 {
+			b.write(" ORDER BY ")
+		} else {
+			b.write(", ")
+		}
+		b.ident(o.column)
+		if o.desc {
+			b.write(" DESC")
+		}
+	}
+}
+
+func (b *buf) result() (string, []interface{}, error) {
+	if b.err != nil {
+		return "", nil, b.err
+	}
+	return b.sb.String(), b.args, nil
+}
@@ -0,0 +1,261 @@
+package sqlbuilder
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type statement interface {
+	ToSQL() (string, []interface{}, error)
+}
+
+type sqlCase struct {
+	name string
+	stmt statement
+	sql  string
+	args []interface{}
+	err  string
+}
+
+func runSQLCases(t *testing.T, tests []sqlCase) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := tt.stmt.ToSQL()
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("err = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sql != tt.sql {
+				t.Errorf("sql\n got %s\nwant %s", sql, tt.sql)
+			}
+			if len(args) != 0 || len(tt.args) != 0 {
+				if !reflect.DeepEqual(args, tt.args) {
+					t.Errorf("args = %#v, want %#v", args, tt.args)
+				}
+			}
+		})
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		in      string
+		want    string
+	}{
+		{MySQL, "users", "`users`"},
+		{MySQL, "app.users", "`app`.`users`"},
+		{MySQL, "u.*", "`u`.*"},
+		{MySQL, "we`ird", "`we``ird`"},
+		{Postgres, "users", `"users"`},
+		{Postgres, "public.users", `"public"."users"`},
+		{Postgres, `we"ird`, `"we""ird"`},
+		{Postgres, "*", "*"},
+	}
+	for _, tt := range tests {
+		if got := tt.dialect.Quote(tt.in); got != tt.want {
+			t.Errorf("Quote(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	var nilName *string
+	name := "ada"
+
+	runSQLCases(t, []sqlCase{
+		{
+			name: "mysql where and order",
+			stmt: MySQL.Select("id", "name").From("app.users").
+				Where(Eq("status", "active"), If(false, Like("name", "x%")), Gt("age", 18)).
+				OrderByDesc("id").Limit(10),
+			sql:  "SELECT `id`, `name` FROM `app`.`users` WHERE (`status` = ? AND `age` > ?) ORDER BY `id` DESC LIMIT 10",
+			args: []interface{}{"active", 18},
+		},
+		{
+			name: "postgres renumbering across nested groups",
+			stmt: Postgres.Select().From("users").
+				Where(
+					Eq("a", 1),
+					Or(Eq("b", 2), And(In("c", 3, 4), Not(Between("d", 5, 6)))),
+					Expr("lower(e) = ? OR f = ?", "x", "y"),
+				),
+			sql:  `SELECT * FROM "users" WHERE ("a" = $1 AND ("b" = $2 OR ("c" IN ($3, $4) AND NOT ("d" BETWEEN $5 AND $6))) AND lower(e) = $7 OR f = $8)`,
+			args: []interface{}{1, 2, 3, 4, 5, 6, "x", "y"},
+		},
+		{
+			name: "postgres joins with aliases",
+			stmt: Postgres.Select("u.id", Expr("COUNT(*)")).From("users u").
+				Join("orders o", And(On("o.user_id", "u.id"), Gt("o.total", 100))).
+				LeftJoin("refunds r", On("r.order_id", "o.id")).
+				Where(Eq("u.org", 7)).
+				GroupBy("u.id").
+				Having(Expr("COUNT(*) > ?", 2)),
+			sql: `SELECT "u"."id", COUNT(*) FROM "users" "u" JOIN "orders" "o" ON ("o"."user_id" = "u"."id" AND "o"."total" > $1)` +
+				` LEFT JOIN "refunds" "r" ON "r"."order_id" = "o"."id" WHERE "u"."org" = $2 GROUP BY "u"."id" HAVING COUNT(*) > $3`,
+			args: []interface{}{100, 7, 2},
+		},
+		{
+			name: "empty in lists",
+			stmt: MySQL.Select().From("t").Where(In("a"), NotIn("b")),
+			sql:  "SELECT * FROM `t` WHERE (1 = 0 AND 1 = 1)",
+		},
+		{
+			name: "nil values become IS NULL",
+			stmt: Postgres.Select().From("t").Where(Eq("a", nil), Neq("b", nilName), Eq("c", &name)),
+			sql:  `SELECT * FROM "t" WHERE ("a" IS NULL AND "b" IS NOT NULL AND "c" = $1)`,
+			args: []interface{}{&name},
+		},
+		{
+			name: "postgres limit and offset",
+			stmt: Postgres.Select().From("t").Limit(5).Offset(10),
+			sql:  `SELECT * FROM "t" LIMIT 5 OFFSET 10`,
+		},
+		{
+			name: "postgres offset without limit",
+			stmt: Postgres.Select().From("t").Offset(10),
+			sql:  `SELECT * FROM "t" OFFSET 10`,
+		},
+		{
+			name: "mysql offset without limit",
+			stmt: MySQL.Select().From("t").Offset(10),
+			sql:  "SELECT * FROM `t` LIMIT 18446744073709551615 OFFSET 10",
+		},
+		{
+			name: "limit zero",
+			stmt: MySQL.Select().From("t").Limit(0),
+			sql:  "SELECT * FROM `t` LIMIT 0",
+		},
+		{
+			name: "no table",
+			stmt: MySQL.Select(),
+			err:  "no table",
+		},
+		{
+			name: "unknown column type",
+			stmt: MySQL.Select("id", 42).From("t"),
+			err:  "column must be a string or Raw, got int",
+		},
+	})
+}
+
+func TestInsert(t *testing.T) {
+	runSQLCases(t, []sqlCase{
+		{
+			name: "mysql multi-row",
+			stmt: MySQL.InsertInto("t").Columns("a", "b").Values(1, "x").Values(2, Expr("NOW()")),
+			sql:  "INSERT INTO `t` (`a`, `b`) VALUES (?, ?), (?, NOW())",
+			args: []interface{}{1, "x", 2},
+		},
+		{
+			name: "mysql upsert",
+			stmt: MySQL.InsertInto("t").Columns("id", "n").Values(1, 2).Upsert([]string{"id"}, "n"),
+			sql:  "INSERT INTO `t` (`id`, `n`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `n` = VALUES(`n`)",
+			args: []interface{}{1, 2},
+		},
+		{
+			name: "mysql upsert without update columns",
+			stmt: MySQL.InsertInto("t").Columns("id", "n").Values(1, 2).Upsert(nil),
+			sql:  "INSERT INTO `t` (`id`, `n`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `id` = `id`",
+			args: []interface{}{1, 2},
+		},
+		{
+			name: "postgres upsert with returning",
+			stmt: Postgres.InsertInto("app.t").Columns("id", "n").Values(1, 2).Values(3, 4).
+				Upsert([]string{"id"}, "n").Returning("id"),
+			sql:  `INSERT INTO "app"."t" ("id", "n") VALUES ($1, $2), ($3, $4) ON CONFLICT ("id") DO UPDATE SET "n" = EXCLUDED."n" RETURNING "id"`,
+			args: []interface{}{1, 2, 3, 4},
+		},
+		{
+			name: "postgres upsert do nothing",
+			stmt: Postgres.InsertInto("t").Columns("id").Values(1).Upsert(nil),
+			sql:  `INSERT INTO "t" ("id") VALUES ($1) ON CONFLICT DO NOTHING`,
+			args: []interface{}{1},
+		},
+		{
+			name: "postgres upsert update without conflict columns",
+			stmt: Postgres.InsertInto("t").Columns("id", "n").Values(1, 2).Upsert(nil, "n"),
+			err:  "needs conflict columns",
+		},
+		{
+			name: "mysql returning",
+			stmt: MySQL.InsertInto("t").Columns("id").Values(1).Returning("id"),
+			err:  "RETURNING is not supported",
+		},
+		{
+			name: "row length mismatch",
+			stmt: MySQL.InsertInto("t").Columns("a", "b").Values(1),
+			err:  "row 0 has 1 values for 2 columns",
+		},
+	})
+}
+
+func TestUpdateDelete(t *testing.T) {
+	runSQLCases(t, []sqlCase{
+		{
+			name: "postgres update renumbers set and where",
+			stmt: Postgres.Update("t").Set("n", Expr("n + ?", 1)).Set("s", "x").
+				Where(Eq("id", 9), Or(Eq("a", nil), Lt("b", 3))).Returning("n"),
+			sql:  `UPDATE "t" SET "n" = n + $1, "s" = $2 WHERE ("id" = $3 AND ("a" IS NULL OR "b" < $4)) RETURNING "n"`,
+			args: []interface{}{1, "x", 9, 3},
+		},
+		{
+			name: "mysql update",
+			stmt: MySQL.Update("t").Set("s", "x").SetIf(false, "skipped", 1).Where(Eq("id", 9)),
+			sql:  "UPDATE `t` SET `s` = ? WHERE `id` = ?",
+			args: []interface{}{"x", 9},
+		},
+		{
+			name: "update without where",
+			stmt: MySQL.Update("t").Set("s", "x"),
+			err:  "no WHERE condition",
+		},
+		{
+			name: "update with every condition dropped",
+			stmt: Postgres.Update("t").Set("s", "x").Where(If(false, Eq("id", 1))),
+			err:  "no WHERE condition",
+		},
+		{
+			name: "update all",
+			stmt: Postgres.Update("t").Set("s", "x").All(),
+			sql:  `UPDATE "t" SET "s" = $1`,
+			args: []interface{}{"x"},
+		},
+		{
+			name: "update without set",
+			stmt: MySQL.Update("t").Where(Eq("id", 1)),
+			err:  "update without SET",
+		},
+		{
+			name: "delete",
+			stmt: Postgres.DeleteFrom("t").Where(In("id", 1, 2)),
+			sql:  `DELETE FROM "t" WHERE "id" IN ($1, $2)`,
+			args: []interface{}{1, 2},
+		},
+		{
+			name: "delete without where",
+			stmt: MySQL.DeleteFrom("t").Where(If(false, Eq("id", 1))),
+			err:  "no WHERE condition",
+		},
+		{
+			name: "delete all",
+			stmt: MySQL.DeleteFrom("t").All(),
+			sql:  "DELETE FROM `t`",
+		},
+	})
+}
+
+func TestErrNoWhere(t *testing.T) {
+	_, _, err := MySQL.DeleteFrom("t").ToSQL()
+	if !errors.Is(err, errNoWhere) {
+		t.Fatalf("err = %v, want errNoWhere", err)
+	}
+}
@@ -0,0 +1,212 @@
+package sqlbuilder
+
+import "reflect"
+
+// Cond is a WHERE, HAVING or JOIN condition. A nil Cond is skipped, which
+// lets If drop optional filters.
+type Cond interface {
+	build(b *buf)
+}
+
+type compare struct {
+	column string
+	op     string
+	value  interface{}
+}
+
+func (c compare) build(b *buf) {
+	b.ident(c.column)
+	b.write(" " + c.op + " ")
+	b.arg(c.value)
+}
+
+// Eq and Neq render IS NULL and IS NOT NULL for a nil value, including a
+// nil pointer, since = NULL never matches.
+func Eq(column string, value interface{}) Cond {
+	if isNil(value) {
+		return IsNull(column)
+	}
+	return compare{column, "=", value}
+}
+
+func Neq(column string, value interface{}) Cond {
+	if isNil(value) {
+		return IsNotNull(column)
+	}
+	return compare{column, "<>", value}
+}
+
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+func Gt(column string, value interface{}) Cond   { return compare{column, ">", value} }
+func Gte(column string, value interface{}) Cond  { return compare{column, ">=", value} }
+func Lt(column string, value interface{}) Cond   { return compare{column, "<", value} }
+func Lte(column string, value interface{}) Cond  { return compare{column, "<=", value} }
+func Like(column string, value interface{}) Cond { return compare{column, "LIKE", value} }
+
+type in struct {
+	column string
+	not    bool
+	values []interface{}
+}
+
+func (c in) build(b *buf) {
+	// An empty list matches nothing (or everything for NOT IN) instead of
+	// producing invalid SQL.
+	if len(c.values) == 0 {
+		if c.not {
+			b.write("1 = 1")
+		} else {
+			b.write("1 = 0")
+		}
+		return
+	}
+
+	b.ident(c.column)
+	if c.not {
+		b.write(" NOT")
+	}
+	b.write(" IN (")
+	for i, v := range c.values {
+		if i > 0 {
+			b.write(", ")
+		}
+		b.arg(v)
+	}
+	b.write(")")
+}
+
+func In(column string, values ...interface{}) Cond    { return in{column, false, values} }
+func NotIn(column string, values ...interface{}) Cond { return in{column, true, values} }
+
+type null struct {
+	column string
+	not    bool
+}
+
+func (c null) build(b *buf) {
+	b.ident(c.column)
+	if c.not {
+		b.write(" IS NOT NULL")
+	} else {
+		b.write(" IS NULL")
+	}
+}
+
+func IsNull(column string) Cond    { return null{column, false} }
+func IsNotNull(column string) Cond { return null{column, true} }
+
+type between struct {
+	column   string
+	from, to interface{}
+}
+
+func (c between) build(b *buf) {
+	b.ident(c.column)
+	b.write(" BETWEEN ")
+	b.arg(c.from)
+	b.write(" AND ")
+	b.arg(c.to)
+}
+
+func Between(column string, from, to interface{}) Cond { return between{column, from, to} }
+
+type columns struct {
+	left, op, right string
+}
+
+func (c columns) build(b *buf) {
+	b.ident(c.left)
+	b.write(" " + c.op + " ")
+	b.ident(c.right)
+}
+
+// On compares two columns, typically for a JOIN.
+func On(left, right string) Cond {
+	return columns{left, "=", right}
+}
+
+type group struct {
+	op    string
+	conds []Cond
+}
+
+func (c group) build(b *buf) {
+	if len(c.conds) == 1 {
+		c.conds[0].build(b)
+		return
+	}
+	b.write("(")
+	for i, cond := range c.conds {
+		if i > 0 {
+			b.write(" " + c.op + " ")
+		}
+		cond.build(b)
+	}
+	b.write(")")
+}
+
+func compact(conds []Cond) []Cond {
+	out := make([]Cond, 0, len(conds))
+	for _, c := range conds {
+		if c != nil {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// And joins conds with AND, skipping nil ones. It is nil when all are.
+func And(conds ...Cond) Cond {
+	conds = compact(conds)
+	if len(conds) == 0 {
+		return nil
+	}
+	return group{"AND", conds}
+}
+
+// Or joins conds with OR, skipping nil ones. It is nil when all are.
+func Or(conds ...Cond) Cond {
+	conds = compact(conds)
+	if len(conds) == 0 {
+		return nil
+	}
+	return group{"OR", conds}
+}
+
+type not struct {
+	cond Cond
+}
+
+func (c not) build(b *buf) {
+	b.write("NOT (")
+	c.cond.build(b)
+	b.write(")")
+}
+
+func Not(cond Cond) Cond {
+	if cond == nil {
+		return nil
+	}
+	return not{cond}
+}
+
+func (r Raw) build(b *buf) {
+	b.raw(r)
+}
+
+// If returns cond when ok and nil otherwise, for optional filters:
+//
+//	q.Where(sqlbuilder.If(name != "", sqlbuilder.Eq("name", name)))
+func If(ok bool, cond Cond) Cond {
+	if !ok {
+		return nil
+	}
+	return cond
+}
@@ -0,0 +1,146 @@
+package sqlbuilder
+
+import (
+	"errors"
+	"fmt"
+)
+
+type InsertBuilder struct {
+	dialect   Dialect
+	table     string
+	columns   []string
+	rows      [][]interface{}
+	upsert    bool
+	conflict  []string
+	update    []string
+	returning []string
+}
+
+func (d Dialect) InsertInto(table string) *InsertBuilder {
+	return &InsertBuilder{
+		dialect: d,
+		table:   table,
+	}
+}
+
+func (s *InsertBuilder) Columns(columns ...string) *InsertBuilder {
+	s.columns = columns
+	return s
+}
+
+// Values adds one row. Call it repeatedly for a multi-row insert.
+func (s *InsertBuilder) Values(values ...interface{}) *InsertBuilder {
+	s.rows = append(s.rows, values)
+	return s
+}
+
+// Upsert updates the listed columns with the inserted values when a row
+// with the same key exists. conflict names the unique key columns for
+// ON CONFLICT; MySQL ignores it and uses any duplicate key. With no update
+// columns duplicates are left as they are.
+func (s *InsertBuilder) Upsert(conflict []string, update ...string) *InsertBuilder {
+	s.upsert = true
+	s.conflict = conflict
+	s.update = update
+	return s
+}
+
+// Returning adds a RETURNING clause. Postgres only.
+func (s *InsertBuilder) Returning(columns ...string) *InsertBuilder {
+	s.returning = columns
+	return s
+}
+
+func (s *InsertBuilder) ToSQL() (string, []interface{}, error) {
+	if s.table == "" {
+		return "", nil, errNoTable
+	}
+	if len(s.columns) == 0 {
+		return "", nil, errNoColumns
+	}
+	if len(s.rows) == 0 {
+		return "", nil, errors.New("sqlbuilder: insert without values")
+	}
+	if s.upsert && s.dialect == Postgres && len(s.conflict) == 0 && len(s.update) > 0 {
+		return "", nil, errors.New("sqlbuilder: ON CONFLICT DO UPDATE needs conflict columns")
+	}
+	if len(s.returning) > 0 && s.dialect != Postgres {
+		return "", nil, errors.New("sqlbuilder: RETURNING is not supported by MySQL")
+	}
+
+	b := &buf{dialect: s.dialect}
+	b.write("INSERT INTO ")
+	b.ident(s.table)
+	b.write(" (")
+	b.idents(s.columns)
+	b.write(") VALUES ")
+	for i, row := range s.rows {
+		if len(row) != len(s.columns) {
+			return "", nil, fmt.Errorf("sqlbuilder: row %d has %d values for %d columns", i, len(row), len(s.columns))
+		}
+		if i > 0 {
+			b.write(", ")
+		}
+		b.write("(")
+		for j, v := range row {
+			if j > 0 {
+				b.write(", ")
+			}
+			b.arg(v)
+		}
+		b.write(")")
+	}
+
+	if s.upsert {
+		s.buildUpsert(b)
+	}
+	if len(s.returning) > 0 {
+		b.write(" RETURNING ")
+		b.idents(s.returning)
+	}
+	return b.result()
+}
+
+func (s *InsertBuilder) buildUpsert(b *buf) {
+	if s.dialect == Postgres {
+		b.write(" ON CONFLICT")
+		if len(s.conflict) > 0 {
+			b.write(" (")
+			b.idents(s.conflict)
+			b.write(")")
+		}
+		if len(s.update) == 0 {
+			b.write(" DO NOTHING")
+			return
+		}
+		b.write(" DO UPDATE SET ")
+		for i, c := range s.update {
+			if i > 0 {
+				b.write(", ")
+			}
+			b.ident(c)
+			b.write(" = EXCLUDED.")
+			b.ident(c)
+		}
+		return
+	}
+
+	b.write(" ON DUPLICATE KEY UPDATE ")
+	if len(s.update) == 0 {
+		// A no-op assignment keeps the existing row without the error
+		// suppression of INSERT IGNORE.
+		b.ident(s.columns[0])
+		b.write(" = ")
+		b.ident(s.columns[0])
+		return
+	}
+	for i, c := range s.update {
+		if i > 0 {
+			b.write(", ")
+		}
+		b.ident(c)
+		b.write(" = VALUES(")
+		b.ident(c)
+		b.write(")")
+	}
+}
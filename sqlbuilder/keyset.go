@@ -0,0 +1,218 @@
+package sqlbuilder
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultPageLimit = 20
+
+var ErrInvalidCursor = errors.New("sqlbuilder: invalid cursor")
+
+// Keyset describes one page of a keyset (seek) paginated query. Columns
+// must be selected, NOT NULL and together unique, e.g. {"created_at", "id"},
+// and are all sorted in the same direction. A NULL key cannot be compared
+// with > or <, so rows holding one would be skipped silently.
+type Keyset struct {
+	Columns []string
+	Desc    bool
+	// Limit is the page size. Defaults to 20.
+	Limit int
+	// Cursor is the NextCursor of the previous page, empty for the first.
+	Cursor string
+}
+
+// Page returns a copy of s that selects the page described by k. It fetches
+// one extra row so NextCursor can tell whether another page follows.
+func (s *SelectBuilder) Page(k Keyset) (*SelectBuilder, error) {
+	if len(k.Columns) == 0 {
+		return nil, errors.New("sqlbuilder: keyset without columns")
+	}
+	limit := k.Limit
+	if limit <= 0 {
+		limit = defaultPageLimit
+	}
+
+	page := *s
+	page.where = append([]Cond(nil), s.where...)
+	page.orders = nil
+	for _, c := range k.Columns {
+		page.orders = append(page.orders, order{c, k.Desc})
+	}
+	page.limit = limit + 1
+	page.offset = 0
+
+	if k.Cursor == "" {
+		return &page, nil
+	}
+
+	values, err := DecodeCursor(k.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != len(k.Columns) {
+		return nil, ErrInvalidCursor
+	}
+	for _, v := range values {
+		if v == nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+	page.where = append(page.where, keysetCond{k.Columns, k.Desc, values})
+	return &page, nil
+}
+
+// keysetCond is the row value comparison (a, b) > (?, ?), which both
+// MySQL and PostgreSQL evaluate lexicographically.
+type keysetCond struct {
+	columns []string
+	desc    bool
+	values  []interface{}
+}
+
+func (c keysetCond) build(b *buf) {
+	b.write("(")
+	b.idents(c.columns)
+	if c.desc {
+		b.write(") < (")
+	} else {
+		b.write(") > (")
+	}
+	for i, v := range c.values {
+		if i > 0 {
+			b.write(", ")
+		}
+		b.arg(v)
+	}
+	b.write(")")
+}
+
+// NextCursor drops the extra row fetched by Page and returns the rows of the
+// page with the cursor of the next one, empty on the last page. It returns
+// ErrInvalidCursor when a key column of the last row is NULL or missing.
+func NextCursor(k Keyset, rows []map[string]interface{}) ([]map[string]interface{}, string, error) {
+	limit := k.Limit
+	if limit <= 0 {
+		limit = defaultPageLimit
+	}
+	if len(rows) <= limit {
+		return rows, "", nil
+	}
+
+	rows = rows[:limit]
+	last := rows[limit-1]
+	values := make([]interface{}, len(k.Columns))
+	for i, c := range k.Columns {
+		// Result maps are keyed by bare column name.
+		if dot := strings.LastIndexByte(c, '.'); dot >= 0 {
+			c = c[dot+1:]
+		}
+		if last[c] == nil {
+			return nil, "", fmt.Errorf("%w: key column %s is NULL", ErrInvalidCursor, c)
+		}
+		values[i] = last[c]
+	}
+	return rows, EncodeCursor(values...), nil
+}
+
+// EncodeCursor packs key values into an opaque URL-safe string. Each value
+// keeps its type so it binds back as the same parameter type.
+func EncodeCursor(values ...interface{}) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = encodeCursorValue(v)
+	}
+	js, _ := json.Marshal(parts)
+	return base64.RawURLEncoding.EncodeToString(js)
+}
+
+func encodeCursorValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "n:"
+	case string:
+		return "s:" + val
+	case []byte:
+		return "s:" + string(val)
+	case bool:
+		return "b:" + strconv.FormatBool(val)
+	case int:
+		return "i:" + strconv.FormatInt(int64(val), 10)
+	case int8:
+		return "i:" + strconv.FormatInt(int64(val), 10)
+	case int16:
+		return "i:" + strconv.FormatInt(int64(val), 10)
+	case int32:
+		return "i:" + strconv.FormatInt(int64(val), 10)
+	case int64:
+		return "i:" + strconv.FormatInt(val, 10)
+	case uint:
+		return "u:" + strconv.FormatUint(uint64(val), 10)
+	case uint32:
+		return "u:" + strconv.FormatUint(uint64(val), 10)
+	case uint64:
+		return "u:" + strconv.FormatUint(val, 10)
+	case float32:
+		return "f:" + strconv.FormatFloat(float64(val), 'g', -1, 32)
+	case float64:
+		return "f:" + strconv.FormatFloat(val, 'g', -1, 64)
+	case time.Time:
+		return "t:" + val.Format(time.RFC3339Nano)
+	case [16]byte:
+		// pgx returns uuid columns as [16]byte.
+		h := hex.EncodeToString(val[:])
+		return "s:" + h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+	default:
+		return "s:" + fmt.Sprint(val)
+	}
+}
+
+// DecodeCursor unpacks a cursor made by EncodeCursor.
+func DecodeCursor(cursor string) ([]interface{}, error) {
+	js, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var parts []string
+	if err := json.Unmarshal(js, &parts); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	values := make([]interface{}, len(parts))
+	for i, p := range parts {
+		kind, raw, ok := strings.Cut(p, ":")
+		if !ok {
+			return nil, ErrInvalidCursor
+		}
+
+		var err error
+		switch kind {
+		case "n":
+			values[i] = nil
+		case "s":
+			values[i] = raw
+		case "b":
+			values[i], err = strconv.ParseBool(raw)
+		case "i":
+			values[i], err = strconv.ParseInt(raw, 10, 64)
+		case "u":
+			values[i], err = strconv.ParseUint(raw, 10, 64)
+		case "f":
+			values[i], err = strconv.ParseFloat(raw, 64)
+		case "t":
+			values[i], err = time.Parse(time.RFC3339Nano, raw)
+		default:
+			err = ErrInvalidCursor
+		}
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,168 @@
+package sqlbuilder
+
+import "strconv"
+
+type join struct {
+	kind  string
+	table string
+	on    Cond
+}
+
+type SelectBuilder struct {
+	dialect  Dialect
+	columns  []interface{}
+	table    string
+	joins    []join
+	where    []Cond
+	groupBy  []string
+	having   []Cond
+	orders   []order
+	limit    int
+	offset   int
+	distinct bool
+}
+
+// Select starts a SELECT of columns, which are column names or Raw
+// expressions. No columns selects *.
+func (d Dialect) Select(columns ...interface{}) *SelectBuilder {
+	return &SelectBuilder{
+		dialect: d,
+		columns: columns,
+		limit:   -1,
+	}
+}
+
+func (s *SelectBuilder) Distinct() *SelectBuilder {
+	s.distinct = true
+	return s
+}
+
+// From sets the table. It may carry an alias: "users u".
+func (s *SelectBuilder) From(table string) *SelectBuilder {
+	s.table = table
+	return s
+}
+
+// Join adds an INNER JOIN. table may carry an alias: "orders o".
+func (s *SelectBuilder) Join(table string, on Cond) *SelectBuilder {
+	s.joins = append(s.joins, join{"JOIN", table, on})
+	return s
+}
+
+func (s *SelectBuilder) LeftJoin(table string, on Cond) *SelectBuilder {
+	s.joins = append(s.joins, join{"LEFT JOIN", table, on})
+	return s
+}
+
+func (s *SelectBuilder) RightJoin(table string, on Cond) *SelectBuilder {
+	s.joins = append(s.joins, join{"RIGHT JOIN", table, on})
+	return s
+}
+
+// Where adds conditions joined with AND. Nil conditions are skipped.
+func (s *SelectBuilder) Where(conds ...Cond) *SelectBuilder {
+	s.where = append(s.where, conds...)
+	return s
+}
+
+func (s *SelectBuilder) GroupBy(columns ...string) *SelectBuilder {
+	s.groupBy = append(s.groupBy, columns...)
+	return s
+}
+
+func (s *SelectBuilder) Having(conds ...Cond) *SelectBuilder {
+	s.having = append(s.having, conds...)
+	return s
+}
+
+func (s *SelectBuilder) OrderBy(column string) *SelectBuilder {
+	s.orders = append(s.orders, order{column, false})
+	return s
+}
+
+func (s *SelectBuilder) OrderByDesc(column string) *SelectBuilder {
+	s.orders = append(s.orders, order{column, true})
+	return s
+}
+
+func (s *SelectBuilder) Limit(n int) *SelectBuilder {
+	s.limit = n
+	return s
+}
+
+func (s *SelectBuilder) Offset(n int) *SelectBuilder {
+	s.offset = n
+	return s
+}
+
+// tableRef writes "table alias" with both parts quoted.
+func tableRef(b *buf, table string) {
+	name, alias := table, ""
+	for i := len(table) - 1; i >= 0; i-- {
+		if table[i] == ' ' {
+			name, alias = table[:i], table[i+1:]
+			break
+		}
+	}
+	b.ident(name)
+	if alias != "" {
+		b.write(" ")
+		b.ident(alias)
+	}
+}
+
+func (s *SelectBuilder) ToSQL() (string, []interface{}, error) {
+	if s.table == "" {
+		return "", nil, errNoTable
+	}
+
+	b := &buf{dialect: s.dialect}
+	b.write("SELECT ")
+	if s.distinct {
+		b.write("DISTINCT ")
+	}
+	if len(s.columns) == 0 {
+		b.write("*")
+	}
+	for i, c := range s.columns {
+		if i > 0 {
+			b.write(", ")
+		}
+		b.column(c)
+	}
+
+	b.write(" FROM ")
+	tableRef(b, s.table)
+	for _, j := range s.joins {
+		b.write(" " + j.kind + " ")
+		tableRef(b, j.table)
+		if j.on != nil {
+			b.write(" ON ")
+			j.on.build(b)
+		}
+	}
+
+	b.where(s.where)
+	if len(s.groupBy) > 0 {
+		b.write(" GROUP BY ")
+		b.idents(s.groupBy)
+	}
+	if c := And(s.having...); c != nil {
+		b.write(" HAVING ")
+		c.build(b)
+	}
+	b.orderBy(s.orders)
+
+	if s.limit >= 0 {
+		b.write(" LIMIT " + strconv.Itoa(s.limit))
+	}
+	if s.offset > 0 {
+		// MySQL has no OFFSET without LIMIT; its documented idiom is the
+		// largest unsigned BIGINT.
+		if s.limit < 0 && s.dialect == MySQL {
+			b.write(" LIMIT 18446744073709551615")
+		}
+		b.write(" OFFSET " + strconv.Itoa(s.offset))
+	}
+	return b.result()
+}
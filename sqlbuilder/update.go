@@ -0,0 +1,133 @@
+package sqlbuilder
+
+import "errors"
+
+type assignment struct {
+	column string
+	value  interface{}
+}
+
+type UpdateBuilder struct {
+	dialect   Dialect
+	table     string
+	set       []assignment
+	where     []Cond
+	all       bool
+	returning []string
+}
+
+func (d Dialect) Update(table string) *UpdateBuilder {
+	return &UpdateBuilder{
+		dialect: d,
+		table:   table,
+	}
+}
+
+// Set assigns value to column. value may be a Raw such as
+// Expr("counter + ?", 1).
+func (s *UpdateBuilder) Set(column string, value interface{}) *UpdateBuilder {
+	s.set = append(s.set, assignment{column, value})
+	return s
+}
+
+// SetIf is Set when ok, for partial updates.
+func (s *UpdateBuilder) SetIf(ok bool, column string, value interface{}) *UpdateBuilder {
+	if ok {
+		s.Set(column, value)
+	}
+	return s
+}
+
+// Where adds conditions joined with AND. Nil conditions are skipped.
+func (s *UpdateBuilder) Where(conds ...Cond) *UpdateBuilder {
+	s.where = append(s.where, conds...)
+	return s
+}
+
+// All allows ToSQL without a WHERE condition, updating every row. Without
+// it ToSQL fails when no condition is left, e.g. after If dropped them all.
+func (s *UpdateBuilder) All() *UpdateBuilder {
+	s.all = true
+	return s
+}
+
+// Returning adds a RETURNING clause. Postgres only.
+func (s *UpdateBuilder) Returning(columns ...string) *UpdateBuilder {
+	s.returning = columns
+	return s
+}
+
+func (s *UpdateBuilder) ToSQL() (string, []interface{}, error) {
+	if s.table == "" {
+		return "", nil, errNoTable
+	}
+	if len(s.set) == 0 {
+		return "", nil, errors.New("sqlbuilder: update without SET")
+	}
+	if len(s.returning) > 0 && s.dialect != Postgres {
+		return "", nil, errors.New("sqlbuilder: RETURNING is not supported by MySQL")
+	}
+	if !s.all && And(s.where...) == nil {
+		return "", nil, errNoWhere
+	}
+
+	b := &buf{dialect: s.dialect}
+	b.write("UPDATE ")
+	b.ident(s.table)
+	b.write(" SET ")
+	for i, a := range s.set {
+		if i > 0 {
+			b.write(", ")
+		}
+		b.ident(a.column)
+		b.write(" = ")
+		b.arg(a.value)
+	}
+	b.where(s.where)
+	if len(s.returning) > 0 {
+		b.write(" RETURNING ")
+		b.idents(s.returning)
+	}
+	return b.result()
+}
+
+type DeleteBuilder struct {
+	dialect Dialect
+	table   string
+	where   []Cond
+	all     bool
+}
+
+func (d Dialect) DeleteFrom(table string) *DeleteBuilder {
+	return &DeleteBuilder{
+		dialect: d,
+		table:   table,
+	}
+}
+
+// Where adds conditions joined with AND. Nil conditions are skipped.
+func (s *DeleteBuilder) Where(conds ...Cond) *DeleteBuilder {
+	s.where = append(s.where, conds...)
+	return s
+}
+
+// All allows ToSQL without a WHERE condition, deleting every row.
+func (s *DeleteBuilder) All() *DeleteBuilder {
+	s.all = true
+	return s
+}
+
+func (s *DeleteBuilder) ToSQL() (string, []interface{}, error) {
+	if s.table == "" {
+		return "", nil, errNoTable
+	}
+	if !s.all && And(s.where...) == nil {
+		return "", nil, errNoWhere
+	}
+
+	b := &buf{dialect: s.dialect}
+	b.write("DELETE FROM ")
+	b.ident(s.table)
+	b.where(s.where)
+	return b.result()
+}
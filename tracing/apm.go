@@ -0,0 +1,128 @@
+package tracing
+
+import (
+	"context"
+
+	"go.elastic.co/apm"
+	"go.elastic.co/apm/module/apmhttp"
+)
+
+const (
+	traceparentHeader        = "Traceparent"
+	tracestateHeader         = "Tracestate"
+	elasticTraceparentHeader = "Elastic-Apm-Traceparent"
+)
+
+type remoteParentKey struct{}
+
+type apmTracer struct {
+	tracer *apm.Tracer
+}
+
+type apmSpan struct {
+	ctx  context.Context
+	span *apm.Span
+	tx   *apm.Transaction
+}
+
+// NewAPM returns the Elastic APM backend using apm.DefaultTracer.
+func NewAPM() Tracer {
+	return &apmTracer{}
+}
+
+// NewAPMWithTracer returns the Elastic APM backend bound to t.
+func NewAPMWithTracer(t *apm.Tracer) Tracer {
+	return &apmTracer{tracer: t}
+}
+
+func (a *apmTracer) apmTracer() *apm.Tracer {
+	if a.tracer != nil {
+		return a.tracer
+	}
+	return apm.DefaultTracer
+}
+
+func (a *apmTracer) StartSpan(ctx context.Context, name, spanType string) (context.Context, Span) {
+	if apm.TransactionFromContext(ctx) == nil {
+		// A remote parent without a local transaction means we are the
+		// entry point of this service, e.g. a message consumer.
+		if tc, ok := ctx.Value(remoteParentKey{}).(apm.TraceContext); ok {
+			tx := a.apmTracer().StartTransactionOptions(name, spanType, apm.TransactionOptions{TraceContext: tc})
+			ctx = apm.ContextWithTransaction(ctx, tx)
+			return ctx, &apmSpan{ctx: ctx, tx: tx}
+		}
+	}
+
+	span, ctx := apm.StartSpan(ctx, name, spanType)
+	return ctx, &apmSpan{ctx: ctx, span: span}
+}
+
+func (a *apmTracer) Inject(ctx context.Context, carrier Carrier) {
+	var tc apm.TraceContext
+	if span := apm.SpanFromContext(ctx); span != nil && !span.Dropped() {
+		tc = span.TraceContext()
+	} else if tx := apm.TransactionFromContext(ctx); tx != nil {
+		tc = tx.TraceContext()
+	} else {
+		return
+	}
+
+	traceparent := apmhttp.FormatTraceparentHeader(tc)
+	carrier.Set(traceparentHeader, traceparent)
+	carrier.Set(elasticTraceparentHeader, traceparent)
+	if state := tc.State.String(); state != "" {
+		carrier.Set(tracestateHeader, state)
+	}
+}
+
+func (a *apmTracer) Extract(ctx context.Context, carrier Carrier) context.Context {
+	header := carrier.Get(traceparentHeader)
+	if header == "" {
+		header = carrier.Get(elasticTraceparentHeader)
+	}
+	if header == "" {
+		return ctx
+	}
+
+	tc, err := apmhttp.ParseTraceparentHeader(header)
+	if err != nil {
+		return ctx
+	}
+	if state := carrier.Get(tracestateHeader); state != "" {
+		if ts, err := apmhttp.ParseTracestateHeader(state); err == nil {
+			tc.State = ts
+		}
+	}
+
+	return context.WithValue(ctx, remoteParentKey{}, tc)
+}
+
+func (s *apmSpan) SetAttribute(key string, value interface{}) {
+	if s.tx != nil {
+		s.tx.Context.SetLabel(key, value)
+		return
+	}
+	s.span.Context.SetLabel(key, value)
+}
+
+func (s *apmSpan) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	if s.tx != nil {
+		s.tx.Outcome = "failure"
+	} else if !s.span.Dropped() {
+		s.span.Outcome = "failure"
+	}
+	if e := apm.CaptureError(s.ctx, err); e != nil {
+		e.Send()
+	}
+}
+
+func (s *apmSpan) End() {
+	if s.tx != nil {
+		s.tx.End()
+		return
+	}
+	s.span.End()
+}
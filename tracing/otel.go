@@ -0,0 +1,91 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/danielpnjt/go-library"
+
+type otelTracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+// NewOTel returns the OpenTelemetry backend. Pass the SDK TracerProvider
+// configured with an OTLP exporter; nil falls back to otel.GetTracerProvider.
+// Context is propagated with W3C traceparent/tracestate and baggage.
+func NewOTel(tp trace.TracerProvider) Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+
+	return &otelTracer{
+		tracer: tp.Tracer(instrumentationName),
+		propagator: propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{},
+			propagation.Baggage{},
+		),
+	}
+}
+
+func (o *otelTracer) StartSpan(ctx context.Context, name, spanType string) (context.Context, Span) {
+	ctx, span := o.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("span.type", spanType)),
+	)
+	return ctx, &otelSpan{span: span}
+}
+
+func (o *otelTracer) Inject(ctx context.Context, carrier Carrier) {
+	o.propagator.Inject(ctx, carrier)
+}
+
+func (o *otelTracer) Extract(ctx context.Context, carrier Carrier) context.Context {
+	return o.propagator.Extract(ctx, carrier)
+}
+
+func (s *otelSpan) SetAttribute(key string, value interface{}) {
+	s.span.SetAttributes(toAttribute(key, value))
+}
+
+func (s *otelSpan) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s *otelSpan) End() {
+	s.span.End()
+}
+
+func toAttribute(key string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case float64:
+		return attribute.Float64(key, v)
+	case []string:
+		return attribute.StringSlice(key, v)
+	default:
+		return attribute.String(key, fmt.Sprintf("%v", v))
+	}
+}
@@ -0,0 +1,55 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestOTelStartSpanCreatesChild(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	defer tp.Shutdown(context.Background())
+	tracer := NewOTel(tp)
+
+	ctx, parent := tracer.StartSpan(context.Background(), "parent", "test")
+	_, child := tracer.StartSpan(ctx, "child", "test")
+	child.SetAttribute("db.statement", "SELECT ?")
+	child.End()
+	parent.End()
+
+	spans := exp.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	if spans[0].Name != "child" || spans[0].Parent.SpanID() != trace.SpanContextFromContext(ctx).SpanID() {
+		t.Fatalf("child span %q has parent %s", spans[0].Name, spans[0].Parent.SpanID())
+	}
+}
+
+func TestOTelInjectWritesTraceparent(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	defer tp.Shutdown(context.Background())
+	tracer := NewOTel(tp)
+
+	ctx, span := tracer.StartSpan(context.Background(), "call", "test")
+	defer span.End()
+
+	carrier := MapCarrier{}
+	tracer.Inject(ctx, carrier)
+
+	traceID := trace.SpanContextFromContext(ctx).TraceID().String()
+	if got := carrier.Get("traceparent"); !strings.Contains(got, traceID) {
+		t.Fatalf("traceparent = %q, want trace id %s", got, traceID)
+	}
+
+	extracted := tracer.Extract(context.Background(), carrier)
+	if trace.SpanContextFromContext(extracted).TraceID().String() != traceID {
+		t.Fatal("Extract did not restore the trace id")
+	}
+}
@@ -0,0 +1,168 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+)
+
+// Span is the backend-neutral handle returned by StartSpan.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+// Carrier reads and writes propagation fields, such as the W3C traceparent,
+// on HTTP headers or message headers.
+type Carrier interface {
+	Get(key string) string
+	Set(key string, value string)
+	Keys() []string
+}
+
+// Tracer is implemented by every tracing backend.
+type Tracer interface {
+	StartSpan(ctx context.Context, name, spanType string) (context.Context, Span)
+	Inject(ctx context.Context, carrier Carrier)
+	Extract(ctx context.Context, carrier Carrier) context.Context
+}
+
+var (
+	tracer Tracer = NewAPM()
+)
+
+// Init replaces the tracer used by every wrapper in this module. Elastic APM
+// stays the default so existing services keep their spans without changes.
+func Init(t Tracer) {
+	if t == nil {
+		t = Noop()
+	}
+	tracer = t
+}
+
+func StartSpan(ctx context.Context, name, spanType string) (context.Context, Span) {
+	return tracer.StartSpan(ctx, name, spanType)
+}
+
+func Inject(ctx context.Context, carrier Carrier) {
+	tracer.Inject(ctx, carrier)
+}
+
+func Extract(ctx context.Context, carrier Carrier) context.Context {
+	return tracer.Extract(ctx, carrier)
+}
+
+// HeaderCarrier adapts http.Header to Carrier.
+type HeaderCarrier http.Header
+
+func (h HeaderCarrier) Get(key string) string {
+	return http.Header(h).Get(key)
+}
+
+func (h HeaderCarrier) Set(key string, value string) {
+	http.Header(h).Set(key, value)
+}
+
+func (h HeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// MapCarrier adapts plain string maps, such as Kafka or RabbitMQ message
+// headers, to Carrier.
+type MapCarrier map[string]string
+
+func (m MapCarrier) Get(key string) string {
+	return m[key]
+}
+
+func (m MapCarrier) Set(key string, value string) {
+	m[key] = value
+}
+
+func (m MapCarrier) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type noopTracer struct{}
+
+type noopSpan struct{}
+
+// Noop returns a tracer that records nothing.
+func Noop() Tracer {
+	return noopTracer{}
+}
+
+func (noopTracer) StartSpan(ctx context.Context, name, spanType string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+func (noopTracer) Inject(ctx context.Context, carrier Carrier) {}
+
+func (noopTracer) Extract(ctx context.Context, carrier Carrier) context.Context {
+	return ctx
+}
+
+func (noopSpan) SetAttribute(key string, value interface{}) {}
+
+func (noopSpan) RecordError(err error) {}
+
+func (noopSpan) End() {}
+
+type multiTracer []Tracer
+
+type multiSpan []Span
+
+// Multi fans every span out to all tracers, e.g. APM and OpenTelemetry
+// while migrating between the two.
+func Multi(tracers ...Tracer) Tracer {
+	return multiTracer(tracers)
+}
+
+func (m multiTracer) StartSpan(ctx context.Context, name, spanType string) (context.Context, Span) {
+	spans := make(multiSpan, 0, len(m))
+	for _, t := range m {
+		var span Span
+		ctx, span = t.StartSpan(ctx, name, spanType)
+		spans = append(spans, span)
+	}
+	return ctx, spans
+}
+
+func (m multiTracer) Inject(ctx context.Context, carrier Carrier) {
+	for _, t := range m {
+		t.Inject(ctx, carrier)
+	}
+}
+
+func (m multiTracer) Extract(ctx context.Context, carrier Carrier) context.Context {
+	for _, t := range m {
+		ctx = t.Extract(ctx, carrier)
+	}
+	return ctx
+}
+
+func (m multiSpan) SetAttribute(key string, value interface{}) {
+	for _, s := range m {
+		s.SetAttribute(key, value)
+	}
+}
+
+func (m multiSpan) RecordError(err error) {
+	for _, s := range m {
+		s.RecordError(err)
+	}
+}
+
+func (m multiSpan) End() {
+	for i := len(m) - 1; i >= 0; i-- {
+		m[i].End()
+	}
+}